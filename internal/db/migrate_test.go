@@ -0,0 +1,62 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"chat-service/migrations"
+)
+
+func TestLoadMigrationsOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0010_later.up.sql":    {Data: []byte("SELECT 10")},
+		"0002_second.up.sql":   {Data: []byte("SELECT 2")},
+		"0002_second.down.sql": {Data: []byte("SELECT -2")},
+		"0001_first.up.sql":    {Data: []byte("SELECT 1")},
+		"README.md":            {Data: []byte("ignored")},
+	}
+
+	got, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(got) != 3 || got[0].Version != 1 || got[1].Version != 2 || got[2].Version != 10 {
+		t.Fatalf("unexpected order: %+v", got)
+	}
+	if got[1].Name != "second" || got[1].Up != "SELECT 2" || got[1].Down != "SELECT -2" {
+		t.Fatalf("unexpected migration: %+v", got[1])
+	}
+	if got[2].Down != "" {
+		t.Fatalf("expected migration without down file to stay irreversible")
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidSets(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"bad name":          {"create_users.sql": {Data: []byte("SELECT 1")}},
+		"duplicate version": {"0001_a.up.sql": {Data: []byte("SELECT 1")}, "0001_b.up.sql": {Data: []byte("SELECT 1")}},
+		"down only":         {"0001_a.down.sql": {Data: []byte("SELECT 1")}},
+		"zero version":      {"0000_a.up.sql": {Data: []byte("SELECT 1")}},
+	}
+	for name, fsys := range cases {
+		if _, err := LoadMigrations(fsys); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrationsAreSequential(t *testing.T) {
+	all, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("load embedded migrations: %v", err)
+	}
+	for i, m := range all {
+		if m.Version != i+1 {
+			t.Fatalf("expected version %d, got %d_%s", i+1, m.Version, m.Name)
+		}
+		if strings.TrimSpace(m.Up) == "" {
+			t.Fatalf("migration %d_%s has an empty up script", m.Version, m.Name)
+		}
+	}
+}
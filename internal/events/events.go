@@ -0,0 +1,147 @@
+// Package events defines the domain events published for downstream services, such as
+// notifications and analytics. Each type documents one payload schema; the envelope and
+// routing are handled by telemetry.EventSource, delivery by the outbox package.
+//
+// Compatible changes (new optional fields) keep the schema version. Renaming, removing or
+// changing the meaning of a field requires bumping it.
+package events
+
+import "time"
+
+// Event types, also used as the routing key suffix.
+const (
+	TypeChatStarted          = "chat.started"
+	TypeMessageCreated       = "message.created"
+	TypeMessageEdited        = "message.edited"
+	TypeMessageDeletedForAll = "message.deleted_for_all"
+	TypeGroupCreated         = "group.created"
+	TypeGroupUpdated         = "group.updated"
+	TypeGroupMemberAdded     = "group.member_added"
+	TypeGroupMemberRemoved   = "group.member_removed"
+	TypeGroupMemberRole      = "group.member_role_changed"
+)
+
+// Conversation scopes of message events.
+const (
+	ScopeChat  = "chat"
+	ScopeGroup = "group"
+)
+
+// Reasons a member left a group.
+const (
+	RemovalKicked         = "removed"
+	RemovalLeft           = "left"
+	RemovalAccountDeleted = "account_deleted"
+)
+
+// ChatStarted is published when two users open a private chat for the first time or reopen it.
+type ChatStarted struct {
+	ChatID      int   `json:"chat_id"`
+	UserIDs     []int `json:"user_ids"`
+	InitiatorID int   `json:"initiator_id"`
+}
+
+func (ChatStarted) EventType() string  { return TypeChatStarted }
+func (ChatStarted) SchemaVersion() int { return 1 }
+
+// MessageCreated is published for every message in a chat or group, including group system
+// messages. Exactly one of ChatID and GroupID is set, matching Scope; Kind is set for groups only.
+type MessageCreated struct {
+	Scope            string    `json:"scope"`
+	MessageID        int       `json:"message_id"`
+	Kind             string    `json:"kind,omitempty"`
+	ChatID           int       `json:"chat_id,omitempty"`
+	GroupID          int       `json:"group_id,omitempty"`
+	SenderID         int       `json:"sender_id"`
+	RecipientIDs     []int     `json:"recipient_ids,omitempty"`
+	Content          string    `json:"content"`
+	ReplyToMessageID *int      `json:"reply_to_message_id,omitempty"`
+	AttachmentCount  int       `json:"attachment_count"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (MessageCreated) EventType() string  { return TypeMessageCreated }
+func (MessageCreated) SchemaVersion() int { return 1 }
+
+// MessageEdited is published when the author replaces the content of a message.
+type MessageEdited struct {
+	Scope     string    `json:"scope"`
+	MessageID int       `json:"message_id"`
+	ChatID    int       `json:"chat_id,omitempty"`
+	GroupID   int       `json:"group_id,omitempty"`
+	SenderID  int       `json:"sender_id"`
+	Content   string    `json:"content"`
+	EditedAt  time.Time `json:"edited_at"`
+}
+
+func (MessageEdited) EventType() string  { return TypeMessageEdited }
+func (MessageEdited) SchemaVersion() int { return 1 }
+
+// MessageDeletedForAll is published when a message is removed for every participant.
+type MessageDeletedForAll struct {
+	Scope     string `json:"scope"`
+	MessageID int    `json:"message_id"`
+	ChatID    int    `json:"chat_id,omitempty"`
+	GroupID   int    `json:"group_id,omitempty"`
+	DeletedBy int    `json:"deleted_by"`
+}
+
+func (MessageDeletedForAll) EventType() string  { return TypeMessageDeletedForAll }
+func (MessageDeletedForAll) SchemaVersion() int { return 1 }
+
+// GroupCreated is published when a group is created with its initial members, owner included.
+type GroupCreated struct {
+	GroupID   int    `json:"group_id"`
+	Name      string `json:"name"`
+	OwnerID   int    `json:"owner_id"`
+	MemberIDs []int  `json:"member_ids"`
+}
+
+func (GroupCreated) EventType() string  { return TypeGroupCreated }
+func (GroupCreated) SchemaVersion() int { return 1 }
+
+// GroupUpdated is published when a group's name, description or avatar changes.
+type GroupUpdated struct {
+	GroupID     int    `json:"group_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	AvatarURL   string `json:"avatar_url"`
+	UpdatedBy   int    `json:"updated_by"`
+}
+
+func (GroupUpdated) EventType() string  { return TypeGroupUpdated }
+func (GroupUpdated) SchemaVersion() int { return 1 }
+
+// GroupMemberAdded is published when members join a group after its creation.
+type GroupMemberAdded struct {
+	GroupID int   `json:"group_id"`
+	UserIDs []int `json:"user_ids"`
+	AddedBy int   `json:"added_by"`
+}
+
+func (GroupMemberAdded) EventType() string  { return TypeGroupMemberAdded }
+func (GroupMemberAdded) SchemaVersion() int { return 1 }
+
+// GroupMemberRemoved is published when a member is removed from or leaves a group.
+// NewOwnerID is set when the owner left and ownership was transferred.
+type GroupMemberRemoved struct {
+	GroupID    int    `json:"group_id"`
+	UserID     int    `json:"user_id"`
+	RemovedBy  int    `json:"removed_by"`
+	Reason     string `json:"reason"`
+	NewOwnerID int    `json:"new_owner_id,omitempty"`
+}
+
+func (GroupMemberRemoved) EventType() string  { return TypeGroupMemberRemoved }
+func (GroupMemberRemoved) SchemaVersion() int { return 1 }
+
+// GroupMemberRoleChanged is published when a member is promoted or demoted.
+type GroupMemberRoleChanged struct {
+	GroupID   int    `json:"group_id"`
+	UserID    int    `json:"user_id"`
+	Role      string `json:"role"`
+	ChangedBy int    `json:"changed_by"`
+}
+
+func (GroupMemberRoleChanged) EventType() string  { return TypeGroupMemberRole }
+func (GroupMemberRoleChanged) SchemaVersion() int { return 1 }
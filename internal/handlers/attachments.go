@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"chat-service/internal/models"
+	"chat-service/internal/repositories"
+	"chat-service/internal/storage"
+)
+
+const (
+	// DefaultMaxAttachmentBytes caps the size of a single uploaded file.
+	DefaultMaxAttachmentBytes = 10 << 20
+	// MaxMessageAttachments caps how many attachments one message may carry.
+	MaxMessageAttachments = 10
+
+	maxAttachmentNameRunes = 255
+	// multipartOverhead leaves room for the multipart framing around the uploaded file.
+	multipartOverhead = 64 << 10
+	sniffLen          = 512
+)
+
+// allowedAttachmentTypes lists the content types accepted for upload, as detected from the
+// file contents. Images are served inline, everything else as a download.
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+	"audio/mpeg":      true,
+	"video/mp4":       true,
+}
+
+// AttachmentHandler uploads message attachments and serves them to the conversation members.
+type AttachmentHandler struct {
+	attachmentRepo   repositories.AttachmentRepository
+	store            storage.BlobStore
+	chatRepo         repositories.ChatRepository
+	messageRepo      repositories.MessageRepository
+	groupRepo        repositories.GroupRepository
+	groupMessageRepo repositories.GroupMessageRepository
+	maxBytes         int64
+}
+
+// NewAttachmentHandler constructs an AttachmentHandler.
+func NewAttachmentHandler(attachmentRepo repositories.AttachmentRepository, store storage.BlobStore, chatRepo repositories.ChatRepository, messageRepo repositories.MessageRepository, groupRepo repositories.GroupRepository, groupMessageRepo repositories.GroupMessageRepository) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentRepo:   attachmentRepo,
+		store:            store,
+		chatRepo:         chatRepo,
+		messageRepo:      messageRepo,
+		groupRepo:        groupRepo,
+		groupMessageRepo: groupMessageRepo,
+		maxBytes:         DefaultMaxAttachmentBytes,
+	}
+}
+
+// SetMaxBytes overrides the maximum size of an uploaded file.
+func (h *AttachmentHandler) SetMaxBytes(maxBytes int64) {
+	h.maxBytes = maxBytes
+}
+
+// Upload handles POST /attachments with a multipart "file" field. The file is streamed to blob
+// storage and returned unattached; it is linked to a message by passing its id in attachment_ids.
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes+multipartOverhead)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart form expected"})
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			} else {
+				h.uploadFailed(c, err)
+			}
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+		h.save(c, part.FileName(), part)
+		part.Close()
+		return
+	}
+}
+
+// save sniffs the content type of an uploaded file, writes it to blob storage and records it.
+func (h *AttachmentHandler) save(c *gin.Context, fileName string, body io.Reader) {
+	buffered := bufio.NewReaderSize(body, sniffLen)
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		h.uploadFailed(c, err)
+		return
+	}
+	if len(head) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is empty"})
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !allowedAttachmentTypes[contentType] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "file type not allowed"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	key := time.Now().UTC().Format("2006/01/02") + "/" + uuid.NewString()
+	size, err := h.store.Put(ctx, key, io.LimitReader(buffered, h.maxBytes+1))
+	if err == nil && size > h.maxBytes {
+		err = &http.MaxBytesError{Limit: h.maxBytes}
+	}
+	if err != nil {
+		h.store.Delete(ctx, key)
+		h.uploadFailed(c, err)
+		return
+	}
+
+	attachment, err := h.attachmentRepo.CreateAttachment(ctx, models.Attachment{
+		UploaderID:  c.GetInt("userID"),
+		StorageKey:  key,
+		FileName:    attachmentFileName(fileName),
+		ContentType: contentType,
+		Size:        size,
+	})
+	if err != nil {
+		h.store.Delete(ctx, key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store attachment"})
+		return
+	}
+	c.JSON(http.StatusCreated, attachment)
+}
+
+func (h *AttachmentHandler) uploadFailed(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file too large"})
+		return
+	}
+	log.Printf("attachment upload failed: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store attachment"})
+}
+
+// Download handles GET /attachments/:attachment_id. Attachments are served to the members of the
+// conversation holding the message, as long as the message is visible to them, and unattached
+// uploads only to their uploader. Anything else is reported as not found.
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	attachmentID, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	attachment, err := h.attachmentRepo.GetAttachment(ctx, attachmentID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrAttachmentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load attachment"})
+		return
+	}
+
+	visible, err := h.canView(c, attachment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load attachment"})
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	}
+
+	body, err := h.store.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load attachment"})
+		return
+	}
+	defer body.Close()
+
+	disposition := "attachment"
+	if strings.HasPrefix(attachment.ContentType, "image/") {
+		disposition = "inline"
+	}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, body, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private",
+	})
+}
+
+// canView reports whether the caller may download attachment.
+func (h *AttachmentHandler) canView(c *gin.Context, attachment models.Attachment) (bool, error) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("userID")
+	if attachment.Scope == nil || attachment.MessageID == nil {
+		return attachment.UploaderID == userID, nil
+	}
+
+	if *attachment.Scope == models.AttachmentScopeGroup {
+		msg, err := h.groupMessageRepo.GetGroupMessage(ctx, *attachment.MessageID)
+		if errors.Is(err, repositories.ErrMessageNotFound) {
+			return false, nil
+		}
+		if err != nil || msg.DeletedForAll {
+			return false, err
+		}
+		return h.groupRepo.IsMember(ctx, msg.GroupID, userID)
+	}
+
+	msg, err := h.messageRepo.GetMessage(ctx, *attachment.MessageID)
+	if errors.Is(err, repositories.ErrMessageNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if msg.DeletedForAll || (msg.SenderID == userID && msg.DeletedBySender) || (msg.SenderID != userID && msg.DeletedByReceiver) {
+		return false, nil
+	}
+	return h.chatRepo.IsParticipant(ctx, msg.ChatID, userID)
+}
+
+// attachmentFileName keeps the base name of a client-supplied file name, without control
+// characters and bounded in length.
+func attachmentFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	if runes := []rune(name); len(runes) > maxAttachmentNameRunes {
+		name = string(runes[:maxAttachmentNameRunes])
+	}
+	return name
+}
+
+// messageAttachmentIDs validates the attachments of a new message, dropping duplicates.
+// A message needs content, attachments or both.
+func messageAttachmentIDs(content string, ids []int) ([]int, error) {
+	if content == "" && len(ids) == 0 {
+		return nil, newRequestError(http.StatusBadRequest, "content is required", "invalid request payload")
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	unique := make([]int, 0, len(ids))
+	seen := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, newRequestError(http.StatusBadRequest, "invalid attachment id", "invalid request payload")
+		}
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) > MaxMessageAttachments {
+		return nil, newRequestError(http.StatusBadRequest, "too many attachments", "invalid request payload")
+	}
+	return unique, nil
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"chat-service/internal/mocks"
+	"chat-service/internal/models"
+	"chat-service/internal/repositories"
+	"chat-service/internal/storage"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type attachmentTestDeps struct {
+	attachmentRepo   *mocks.AttachmentRepositoryMock
+	chatRepo         *mocks.ChatRepositoryMock
+	messageRepo      *mocks.MessageRepositoryMock
+	groupRepo        *mocks.GroupRepositoryMock
+	groupMessageRepo *mocks.GroupMessageRepositoryMock
+	store            *storage.LocalStore
+}
+
+func setupAttachmentRouter(t *testing.T) (*gin.Engine, *AttachmentHandler, attachmentTestDeps) {
+	t.Helper()
+
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	deps := attachmentTestDeps{
+		attachmentRepo:   new(mocks.AttachmentRepositoryMock),
+		chatRepo:         new(mocks.ChatRepositoryMock),
+		messageRepo:      new(mocks.MessageRepositoryMock),
+		groupRepo:        new(mocks.GroupRepositoryMock),
+		groupMessageRepo: new(mocks.GroupMessageRepositoryMock),
+		store:            store,
+	}
+	handler := NewAttachmentHandler(deps.attachmentRepo, store, deps.chatRepo, deps.messageRepo, deps.groupRepo, deps.groupMessageRepo)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	r.POST("/attachments", handler.Upload)
+	r.GET("/attachments/:attachment_id", handler.Download)
+	return r, handler, deps
+}
+
+func uploadRequest(t *testing.T, fileName string, content []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", fileName)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/attachments", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestUploadAttachmentSuccess(t *testing.T) {
+	router, _, deps := setupAttachmentRouter(t)
+
+	var stored models.Attachment
+	deps.attachmentRepo.On("CreateAttachment", mock.Anything, mock.MatchedBy(func(a models.Attachment) bool {
+		stored = a
+		return a.UploaderID == 1 && a.FileName == "photo.png" && a.ContentType == "image/png" && a.Size == int64(len(pngHeader))
+	})).Return(models.Attachment{ID: 4, FileName: "photo.png", ContentType: "image/png", Size: int64(len(pngHeader)), URL: "/attachments/4"}, nil).Once()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, uploadRequest(t, `..\..\photo.png`, pngHeader))
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, float64(4), resp["id"])
+	assert.Equal(t, "/attachments/4", resp["url"])
+	assert.NotContains(t, resp, "storage_key")
+
+	rc, err := deps.store.Open(context.Background(), stored.StorageKey)
+	require.NoError(t, err)
+	rc.Close()
+	deps.attachmentRepo.AssertExpectations(t)
+}
+
+func TestUploadAttachmentRejectsDisallowedType(t *testing.T) {
+	router, _, deps := setupAttachmentRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, uploadRequest(t, "photo.png", []byte("<html><script>alert(1)</script></html>")))
+
+	require.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	deps.attachmentRepo.AssertNotCalled(t, "CreateAttachment", mock.Anything, mock.Anything)
+}
+
+func TestUploadAttachmentTooLarge(t *testing.T) {
+	router, handler, deps := setupAttachmentRouter(t)
+	handler.SetMaxBytes(int64(len(pngHeader)) + 4)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, uploadRequest(t, "big.png", append(append([]byte{}, pngHeader...), make([]byte, 16)...)))
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	deps.attachmentRepo.AssertNotCalled(t, "CreateAttachment", mock.Anything, mock.Anything)
+}
+
+func TestUploadAttachmentMissingFile(t *testing.T) {
+	router, _, _ := setupAttachmentRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/attachments", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDownloadChatAttachment(t *testing.T) {
+	router, _, deps := setupAttachmentRouter(t)
+	_, err := deps.store.Put(context.Background(), "k/1", bytes.NewReader(pngHeader))
+	require.NoError(t, err)
+
+	scope, messageID := models.AttachmentScopeChat, 7
+	deps.attachmentRepo.On("GetAttachment", mock.Anything, 4).Return(models.Attachment{
+		ID: 4, UploaderID: 2, StorageKey: "k/1", FileName: "photo.png", ContentType: "image/png",
+		Size: int64(len(pngHeader)), Scope: &scope, MessageID: &messageID,
+	}, nil).Once()
+	deps.messageRepo.On("GetMessage", mock.Anything, 7).Return(models.Message{ID: 7, ChatID: 5, SenderID: 2}, nil).Once()
+	deps.chatRepo.On("IsParticipant", mock.Anything, 5, 1).Return(true, nil).Once()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/attachments/4", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, pngHeader, rec.Body.Bytes())
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, `inline; filename=photo.png`, rec.Header().Get("Content-Disposition"))
+}
+
+func TestDownloadAttachmentHiddenFromOutsiders(t *testing.T) {
+	router, _, deps := setupAttachmentRouter(t)
+
+	groupScope, chatScope, groupMessageID, chatMessageID := models.AttachmentScopeGroup, models.AttachmentScopeChat, 3, 8
+	deps.attachmentRepo.On("GetAttachment", mock.Anything, 1).Return(models.Attachment{ID: 1, UploaderID: 2, StorageKey: "k/1"}, nil).Once()
+	deps.attachmentRepo.On("GetAttachment", mock.Anything, 2).Return(models.Attachment{ID: 2, UploaderID: 2, StorageKey: "k/2", Scope: &groupScope, MessageID: &groupMessageID}, nil).Once()
+	deps.attachmentRepo.On("GetAttachment", mock.Anything, 3).Return(models.Attachment{ID: 3, UploaderID: 2, StorageKey: "k/3", Scope: &chatScope, MessageID: &chatMessageID}, nil).Once()
+	deps.attachmentRepo.On("GetAttachment", mock.Anything, 9).Return(nil, repositories.ErrAttachmentNotFound).Once()
+	deps.groupMessageRepo.On("GetGroupMessage", mock.Anything, 3).Return(models.GroupMessage{ID: 3, GroupID: 6, SenderID: 2}, nil).Once()
+	deps.groupRepo.On("IsMember", mock.Anything, 6, 1).Return(false, nil).Once()
+	deps.messageRepo.On("GetMessage", mock.Anything, 8).Return(models.Message{ID: 8, ChatID: 5, SenderID: 2, DeletedByReceiver: true}, nil).Once()
+
+	for _, id := range []string{"1", "2", "3", "9"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/attachments/"+id, nil))
+		require.Equal(t, http.StatusNotFound, rec.Code, id)
+	}
+	deps.attachmentRepo.AssertExpectations(t)
+	deps.groupRepo.AssertExpectations(t)
+	deps.chatRepo.AssertNotCalled(t, "IsParticipant", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAttachmentFileName(t *testing.T) {
+	assert.Equal(t, "report.pdf", attachmentFileName("C:\\Users\\me\\report.pdf"))
+	assert.Equal(t, "passwd", attachmentFileName("../../etc/passwd"))
+	assert.Equal(t, "ab.txt", attachmentFileName("a\x00b.txt"))
+	assert.Equal(t, "file", attachmentFileName(""))
+}
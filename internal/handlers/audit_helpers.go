@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+
+	"chat-service/internal/telemetry"
 )
 
 const requestIDContextKey = "request_id"
@@ -24,6 +27,13 @@ func requestIDFromContext(c *gin.Context) string {
 	return requestID
 }
 
+// requestContext returns the request context carrying the request id, so that the domain events
+// recorded by repositories, including for operations shared with websocket clients, can be
+// correlated with the request.
+func requestContext(c *gin.Context) context.Context {
+	return telemetry.ContextWithRequestID(c.Request.Context(), requestIDFromContext(c))
+}
+
 func userIDFromContext(c *gin.Context) *int64 {
 	if val, ok := c.Get("userID"); ok {
 		switch userID := val.(type) {
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-service/internal/repositories"
+	"chat-service/internal/telemetry"
+	"chat-service/internal/ws"
+)
+
+// BlockHandler manages the users the caller has blocked.
+type BlockHandler struct {
+	blockRepo repositories.BlockRepository
+	hub       *ws.Hub
+	audit     *telemetry.AuditEmitter
+}
+
+// NewBlockHandler constructs a BlockHandler.
+func NewBlockHandler(blockRepo repositories.BlockRepository, hub *ws.Hub, audit *telemetry.AuditEmitter) *BlockHandler {
+	return &BlockHandler{blockRepo: blockRepo, hub: hub, audit: audit}
+}
+
+// BlockUser handles POST /blocks/:user_id. Blocked users cannot message the caller, and their
+// group messages are hidden from the caller. Blocking twice is a no-op.
+func (h *BlockHandler) BlockUser(c *gin.Context) {
+	blockerID, blockedID, ok := h.parseBlock(c)
+	if !ok {
+		return
+	}
+
+	created, err := h.blockRepo.Block(c.Request.Context(), blockerID, blockedID)
+	if err != nil {
+		h.emitAudit(c, "ERROR", "internal error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not block user"})
+		return
+	}
+
+	if created {
+		h.hub.Block(blockerID, blockedID)
+		h.emitAudit(c, "INFO", "User '"+strconv.Itoa(blockedID)+"' blocked")
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UnblockUser handles DELETE /blocks/:user_id. Unblocking a user who is not blocked is a no-op.
+func (h *BlockHandler) UnblockUser(c *gin.Context) {
+	blockerID, blockedID, ok := h.parseBlock(c)
+	if !ok {
+		return
+	}
+
+	removed, err := h.blockRepo.Unblock(c.Request.Context(), blockerID, blockedID)
+	if err != nil {
+		h.emitAudit(c, "ERROR", "internal error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not unblock user"})
+		return
+	}
+
+	if removed {
+		h.hub.Unblock(blockerID, blockedID)
+		h.emitAudit(c, "INFO", "User '"+strconv.Itoa(blockedID)+"' unblocked")
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *BlockHandler) parseBlock(c *gin.Context) (int, int, bool) {
+	blockedID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil || blockedID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+	userID := c.GetInt("userID")
+	if blockedID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot block yourself"})
+		return 0, 0, false
+	}
+	return userID, blockedID, true
+}
+
+func (h *BlockHandler) emitAudit(c *gin.Context, level, text string) {
+	if h.audit == nil {
+		return
+	}
+	h.audit.Emit(c.Request.Context(), level, text, requestIDFromContext(c), userIDFromContext(c))
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"chat-service/internal/mocks"
+	"chat-service/internal/ws"
+)
+
+func setupBlockRouter(handler *BlockHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	r.POST("/blocks/:user_id", handler.BlockUser)
+	r.DELETE("/blocks/:user_id", handler.UnblockUser)
+	return r
+}
+
+func TestBlockUser(t *testing.T) {
+	blockRepo := new(mocks.BlockRepositoryMock)
+	router := setupBlockRouter(NewBlockHandler(blockRepo, ws.NewHub(), nil))
+
+	blockRepo.On("Block", mock.Anything, 1, 2).Return(true, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/blocks/2", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	blockRepo.AssertExpectations(t)
+}
+
+func TestUnblockUser(t *testing.T) {
+	blockRepo := new(mocks.BlockRepositoryMock)
+	router := setupBlockRouter(NewBlockHandler(blockRepo, ws.NewHub(), nil))
+
+	blockRepo.On("Unblock", mock.Anything, 1, 2).Return(false, nil).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/blocks/2", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	blockRepo.AssertExpectations(t)
+}
+
+func TestBlockUserRejectsInvalidTargets(t *testing.T) {
+	blockRepo := new(mocks.BlockRepositoryMock)
+	router := setupBlockRouter(NewBlockHandler(blockRepo, ws.NewHub(), nil))
+
+	for path, want := range map[string]string{
+		"/blocks/abc": `{"error":"invalid user id"}`,
+		"/blocks/0":   `{"error":"invalid user id"}`,
+		"/blocks/1":   `{"error":"cannot block yourself"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, path)
+		assert.JSONEq(t, want, rec.Body.String(), path)
+	}
+	blockRepo.AssertNotCalled(t, "Block", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBlockUserRepoError(t *testing.T) {
+	blockRepo := new(mocks.BlockRepositoryMock)
+	router := setupBlockRouter(NewBlockHandler(blockRepo, ws.NewHub(), nil))
+
+	blockRepo.On("Block", mock.Anything, 1, 2).Return(false, assert.AnError).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/blocks/2", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
@@ -16,6 +17,9 @@ import (
 	userpb "chat-service/pb/user"
 )
 
+// DefaultEditWindow is how long after sending a message its author may still edit it.
+const DefaultEditWindow = 15 * time.Minute
+
 type userClient interface {
 	AreFriends(ctx context.Context, userID, friendID int) (bool, error)
 	BulkUsers(ctx context.Context, ids []int) ([]*userpb.GetUserResponse, error)
@@ -27,23 +31,32 @@ type ChatHandler struct {
 	messageRepo repositories.MessageRepository
 	userClient  userClient
 	groupRepo   repositories.GroupRepository
+	blockRepo   repositories.BlockRepository
 	hub         *ws.Hub
 	audit       *telemetry.AuditEmitter
+	editWindow  time.Duration
 }
 
 // NewChatHandler builds a ChatHandler.
-func NewChatHandler(chatRepo repositories.ChatRepository, messageRepo repositories.MessageRepository, userClient userClient, groupRepo repositories.GroupRepository, hub *ws.Hub, audit *telemetry.AuditEmitter) *ChatHandler {
+func NewChatHandler(chatRepo repositories.ChatRepository, messageRepo repositories.MessageRepository, userClient userClient, groupRepo repositories.GroupRepository, blockRepo repositories.BlockRepository, hub *ws.Hub, audit *telemetry.AuditEmitter) *ChatHandler {
 	return &ChatHandler{
 		chatRepo:    chatRepo,
 		messageRepo: messageRepo,
 		userClient:  userClient,
 		groupRepo:   groupRepo,
+		blockRepo:   blockRepo,
 		hub:         hub,
 		audit:       audit,
+		editWindow:  DefaultEditWindow,
 	}
 }
 
-// ListChats returns the chats visible to the authenticated user.
+// SetEditWindow overrides how long a message stays editable after it was sent.
+func (h *ChatHandler) SetEditWindow(window time.Duration) {
+	h.editWindow = window
+}
+
+// ListChats returns the chats and groups visible to the authenticated user, most recently active first.
 func (h *ChatHandler) ListChats(c *gin.Context) {
 	userID := c.GetInt("userID")
 
@@ -53,20 +66,35 @@ func (h *ChatHandler) ListChats(c *gin.Context) {
 		return
 	}
 
-	groups, err := h.groupRepo.ListGroupsForUser(c.Request.Context(), userID)
+	groups, err := h.groupRepo.ListGroupSummaries(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load groups"})
 		return
 	}
 
-	friendIDs := make([]int, 0, len(chats))
+	userIDs := make([]int, 0, len(chats)+len(groups))
+	seen := map[int]struct{}{}
+	addUserID := func(id int) {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			userIDs = append(userIDs, id)
+		}
+	}
 	for _, chat := range chats {
-		friendIDs = append(friendIDs, chat.FriendID)
+		addUserID(chat.FriendID)
+		if chat.LastMessage != nil {
+			addUserID(chat.LastMessage.SenderID)
+		}
+	}
+	for _, g := range groups {
+		if g.LastMessage != nil {
+			addUserID(g.LastMessage.SenderID)
+		}
 	}
 
 	usernameByID := map[int]string{}
-	if len(friendIDs) > 0 {
-		users, err := h.userClient.BulkUsers(c.Request.Context(), friendIDs)
+	if len(userIDs) > 0 {
+		users, err := h.userClient.BulkUsers(c.Request.Context(), userIDs)
 		if err != nil {
 			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to load user info"})
 			return
@@ -77,15 +105,32 @@ func (h *ChatHandler) ListChats(c *gin.Context) {
 		}
 	}
 
+	type lastMessageResponse struct {
+		models.MessagePreview
+		SenderUsername string `json:"sender_username,omitempty"`
+	}
+
 	type chatResponse struct {
-		Type           string    `json:"type"`
-		ChatID         int       `json:"chat_id,omitempty"`
-		FriendID       int       `json:"friend_id,omitempty"`
-		FriendUsername string    `json:"friend_username,omitempty"`
-		GroupID        int       `json:"group_id,omitempty"`
-		Name           string    `json:"name,omitempty"`
-		OwnerID        int       `json:"owner_id,omitempty"`
-		CreatedAt      time.Time `json:"created_at"`
+		Type           string               `json:"type"`
+		ChatID         int                  `json:"chat_id,omitempty"`
+		FriendID       int                  `json:"friend_id,omitempty"`
+		FriendUsername string               `json:"friend_username,omitempty"`
+		GroupID        int                  `json:"group_id,omitempty"`
+		Name           string               `json:"name,omitempty"`
+		OwnerID        int                  `json:"owner_id,omitempty"`
+		CreatedAt      time.Time            `json:"created_at"`
+		UnreadCount    int                  `json:"unread_count"`
+		LastReadID     int                  `json:"last_read_message_id"`
+		LastMessage    *lastMessageResponse `json:"last_message,omitempty"`
+		LastActivityAt time.Time            `json:"last_activity_at"`
+		ReadOnlyReason *string              `json:"read_only_reason,omitempty"`
+	}
+
+	preview := func(p *models.MessagePreview) *lastMessageResponse {
+		if p == nil {
+			return nil
+		}
+		return &lastMessageResponse{MessagePreview: *p, SenderUsername: usernameByID[p.SenderID]}
 	}
 
 	responses := make([]chatResponse, 0, len(chats)+len(groups))
@@ -96,19 +141,33 @@ func (h *ChatHandler) ListChats(c *gin.Context) {
 			FriendID:       chat.FriendID,
 			FriendUsername: usernameByID[chat.FriendID],
 			CreatedAt:      chat.Created,
+			UnreadCount:    chat.UnreadCount,
+			LastReadID:     chat.LastReadMessageID,
+			LastMessage:    preview(chat.LastMessage),
+			LastActivityAt: chat.LastActivityAt,
+			ReadOnlyReason: chat.ReadOnlyReason,
 		})
 	}
 
 	for _, g := range groups {
 		responses = append(responses, chatResponse{
-			Type:      "group",
-			GroupID:   g.ID,
-			Name:      g.Name,
-			OwnerID:   g.OwnerID,
-			CreatedAt: g.CreatedAt,
+			Type:           "group",
+			GroupID:        g.ID,
+			Name:           g.Name,
+			OwnerID:        g.OwnerID,
+			CreatedAt:      g.CreatedAt,
+			UnreadCount:    g.UnreadCount,
+			LastReadID:     g.LastReadMessageID,
+			LastMessage:    preview(g.LastMessage),
+			LastActivityAt: g.LastActivityAt,
 		})
 	}
 
+	// Both lists arrive ordered by activity; interleave them into a single inbox order.
+	sort.SliceStable(responses, func(i, j int) bool {
+		return responses[i].LastActivityAt.After(responses[j].LastActivityAt)
+	})
+
 	c.JSON(http.StatusOK, gin.H{"chats": responses})
 }
 
@@ -139,13 +198,26 @@ func (h *ChatHandler) StartChat(c *gin.Context) {
 		return
 	}
 
-	chat, err := h.chatRepo.CreateOrGetChat(c.Request.Context(), userID, req.FriendID)
+	blocked, err := h.blockRepo.IsBlocked(c.Request.Context(), userID, req.FriendID)
+	if err != nil {
+		h.emitAudit(c, "ERROR", "internal error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check blocks"})
+		return
+	}
+	if blocked {
+		h.emitAudit(c, "ERROR", "user is blocked")
+		c.JSON(http.StatusForbidden, gin.H{"error": "user is blocked"})
+		return
+	}
+
+	chat, err := h.chatRepo.CreateOrGetChat(requestContext(c), userID, req.FriendID)
 	if err != nil {
 		h.emitAudit(c, "ERROR", "internal error")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create chat"})
 		return
 	}
 
+	h.hub.SubscribeChat(chat.ID, userID, req.FriendID)
 	h.emitAudit(c, "INFO", "Chat started with '"+strconv.Itoa(req.FriendID)+"'")
 	c.JSON(http.StatusOK, gin.H{"chat_id": chat.ID})
 }
@@ -169,7 +241,12 @@ func (h *ChatHandler) GetChatMessages(c *gin.Context) {
 		return
 	}
 
-	msgs, err := h.messageRepo.GetChatMessagesForUser(c.Request.Context(), chatID, userID)
+	page, ok := parsePageRequest(c)
+	if !ok {
+		return
+	}
+
+	msgs, cursor, err := h.messageRepo.GetChatMessagesForUser(c.Request.Context(), chatID, userID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load messages"})
 		return
@@ -204,7 +281,7 @@ func (h *ChatHandler) GetChatMessages(c *gin.Context) {
 		resp = append(resp, messageResponse{Message: m, SenderUsername: senderNames[m.SenderID]})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"messages": resp})
+	c.JSON(http.StatusOK, gin.H{"messages": resp, "next_cursor": nextCursor(cursor)})
 }
 
 // PostChatMessage stores a chat message and broadcasts it.
@@ -215,29 +292,10 @@ func (h *ChatHandler) PostChatMessage(c *gin.Context) {
 		return
 	}
 
-	userID := c.GetInt("userID")
-	chat, err := h.chatRepo.GetChat(c.Request.Context(), chatID)
-	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, repositories.ErrChatNotFound) {
-			status = http.StatusNotFound
-		}
-		if status == http.StatusNotFound {
-			h.emitAudit(c, "ERROR", "chat not found")
-		} else if status == http.StatusInternalServerError {
-			h.emitAudit(c, "ERROR", "internal error")
-		}
-		c.JSON(status, gin.H{"error": "chat not found"})
-		return
-	}
-	if !isChatParticipant(chat, userID) {
-		h.emitAudit(c, "ERROR", "not allowed")
-		c.JSON(http.StatusForbidden, gin.H{"error": "not a chat member"})
-		return
-	}
-
 	var req struct {
-		Content string `json:"content" binding:"required"`
+		Content          string `json:"content"`
+		ReplyToMessageID *int   `json:"reply_to_message_id"`
+		AttachmentIDs    []int  `json:"attachment_ids"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.emitAudit(c, "ERROR", "invalid request payload")
@@ -245,20 +303,98 @@ func (h *ChatHandler) PostChatMessage(c *gin.Context) {
 		return
 	}
 
-	msg, err := h.messageRepo.CreateChatMessage(c.Request.Context(), chatID, userID, req.Content)
+	msg, err := h.sendMessage(requestContext(c), c.GetInt("userID"), chatID, req.Content, req.ReplyToMessageID, req.AttachmentIDs)
 	if err != nil {
-		h.emitAudit(c, "ERROR", "internal error")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store message"})
+		respondError(c, err, h.emitAudit)
 		return
 	}
 
+	h.emitAudit(c, "INFO", "Message sent")
+	c.JSON(http.StatusCreated, msg)
+}
+
+// sendMessage stores and broadcasts a chat message on behalf of userID.
+func (h *ChatHandler) sendMessage(ctx context.Context, userID, chatID int, content string, replyToID *int, attachmentIDs []int) (models.Message, error) {
+	attachmentIDs, err := messageAttachmentIDs(content, attachmentIDs)
+	if err != nil {
+		return models.Message{}, err
+	}
+
+	chat, err := h.participantChat(ctx, userID, chatID)
+	if err != nil {
+		return models.Message{}, err
+	}
+	if err := h.ensureWritable(ctx, chat, userID); err != nil {
+		return models.Message{}, err
+	}
+	if replyToID != nil {
+		if err := h.validateReplyTarget(ctx, chatID, *replyToID); err != nil {
+			return models.Message{}, err
+		}
+	}
+
+	msg, err := h.messageRepo.CreateChatMessage(ctx, chatID, userID, content, replyToID, attachmentIDs)
+	if err != nil {
+		if errors.Is(err, repositories.ErrAttachmentUnavailable) {
+			return models.Message{}, newRequestError(http.StatusBadRequest, "attachment unavailable", "invalid request payload")
+		}
+		return models.Message{}, newRequestError(http.StatusInternalServerError, "failed to store message", "internal error")
+	}
+
 	// Ensure chat becomes visible again for both sides once a new message is sent.
-	h.chatRepo.UnhideChatForUser(c.Request.Context(), chatID, chat.User1ID)
-	h.chatRepo.UnhideChatForUser(c.Request.Context(), chatID, chat.User2ID)
+	h.chatRepo.UnhideChatForUser(ctx, chatID, chat.User1ID)
+	h.chatRepo.UnhideChatForUser(ctx, chatID, chat.User2ID)
 
+	h.hub.StopChatTyping(chatID, userID)
 	h.hub.BroadcastChatMessage(chatID, msg)
-	h.emitAudit(c, "INFO", "Message sent")
-	c.JSON(http.StatusCreated, msg)
+	return msg, nil
+}
+
+// ensureWritable checks that the chat still accepts messages: it is open and the participants
+// are still friends.
+func (h *ChatHandler) ensureWritable(ctx context.Context, chat models.Chat, userID int) error {
+	if err := h.ensureOpen(ctx, chat, userID); err != nil {
+		return err
+	}
+	friends, err := h.userClient.AreFriends(ctx, userID, chatPeer(chat, userID))
+	if err != nil {
+		return newRequestError(http.StatusBadGateway, "failed to validate friendship", "internal error")
+	}
+	if !friends {
+		return newRequestError(http.StatusForbidden, "users are not friends", "not friends")
+	}
+	return nil
+}
+
+// ensureOpen checks that the chat is not read-only and neither participant blocked the other,
+// which edits and reactions require as well as new messages.
+func (h *ChatHandler) ensureOpen(ctx context.Context, chat models.Chat, userID int) error {
+	if chat.ReadOnlyReason != nil {
+		return newRequestError(http.StatusForbidden, "chat is read-only", "chat is read-only")
+	}
+	blocked, err := h.blockRepo.IsBlocked(ctx, userID, chatPeer(chat, userID))
+	if err != nil {
+		return newRequestError(http.StatusInternalServerError, "failed to check blocks", "internal error")
+	}
+	if blocked {
+		return newRequestError(http.StatusForbidden, "user is blocked", "user is blocked")
+	}
+	return nil
+}
+
+// participantChat loads the chat and ensures userID takes part in it.
+func (h *ChatHandler) participantChat(ctx context.Context, userID, chatID int) (models.Chat, error) {
+	chat, err := h.chatRepo.GetChat(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrChatNotFound) {
+			return models.Chat{}, newRequestError(http.StatusNotFound, "chat not found", "chat not found")
+		}
+		return models.Chat{}, newRequestError(http.StatusInternalServerError, "chat not found", "internal error")
+	}
+	if !isChatParticipant(chat, userID) {
+		return models.Chat{}, newRequestError(http.StatusForbidden, "not a chat member", "not allowed")
+	}
+	return chat, nil
 }
 
 // DeleteMessageForMe performs a soft delete of a message for the caller.
@@ -318,6 +454,63 @@ func (h *ChatHandler) DeleteMessageForAll(c *gin.Context) {
 		return
 	}
 
+	if err := h.deleteForAll(requestContext(c), c.GetInt("userID"), chatID, messageID); err != nil {
+		respondError(c, err, h.emitAudit)
+		return
+	}
+
+	h.emitAudit(c, "INFO", "Message deleted for all")
+	c.Status(http.StatusNoContent)
+}
+
+// deleteForAll deletes the sender's message for both participants and broadcasts the deletion.
+func (h *ChatHandler) deleteForAll(ctx context.Context, userID, chatID, messageID int) error {
+	chat, err := h.chatRepo.GetChat(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrChatNotFound) {
+			return newRequestError(http.StatusNotFound, "chat not found", "chat not found")
+		}
+		return newRequestError(http.StatusInternalServerError, "chat not found", "internal error")
+	}
+	if !isChatParticipant(chat, userID) {
+		return newRequestError(http.StatusForbidden, "not allowed", "not allowed to delete for all")
+	}
+
+	msg, err := h.messageRepo.GetMessage(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMessageNotFound) {
+			return newRequestError(http.StatusNotFound, "message not found", "message not found")
+		}
+		return newRequestError(http.StatusInternalServerError, "message not found", "internal error")
+	}
+	if msg.ChatID != chatID {
+		return newRequestError(http.StatusBadRequest, "message does not belong to chat", "")
+	}
+	if msg.SenderID != userID {
+		return newRequestError(http.StatusForbidden, "only sender can delete for all", "not allowed to delete for all")
+	}
+
+	deleted, err := h.messageRepo.DeleteMessageForAll(ctx, messageID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMessageNotFound) {
+			return newRequestError(http.StatusNotFound, "could not delete message", "message not found")
+		}
+		return newRequestError(http.StatusInternalServerError, "could not delete message", "internal error")
+	}
+
+	if deleted {
+		h.hub.BroadcastDeletion(chatID, messageID)
+	}
+	return nil
+}
+
+// EditMessage replaces the content of a message (sender only, within the edit window).
+func (h *ChatHandler) EditMessage(c *gin.Context) {
+	chatID, messageID, ok := parseIDs(c)
+	if !ok {
+		return
+	}
+
 	userID := c.GetInt("userID")
 	chat, err := h.chatRepo.GetChat(c.Request.Context(), chatID)
 	if err != nil {
@@ -334,10 +527,23 @@ func (h *ChatHandler) DeleteMessageForAll(c *gin.Context) {
 		return
 	}
 	if !isChatParticipant(chat, userID) {
-		h.emitAudit(c, "ERROR", "not allowed to delete for all")
+		h.emitAudit(c, "ERROR", "not allowed to edit")
 		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed"})
 		return
 	}
+	if err := h.ensureOpen(c.Request.Context(), chat, userID); err != nil {
+		respondError(c, err, h.emitAudit)
+		return
+	}
+
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.emitAudit(c, "ERROR", "invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	msg, err := h.messageRepo.GetMessage(c.Request.Context(), messageID)
 	if err != nil {
@@ -347,7 +553,7 @@ func (h *ChatHandler) DeleteMessageForAll(c *gin.Context) {
 		}
 		if status == http.StatusNotFound {
 			h.emitAudit(c, "ERROR", "message not found")
-		} else if status == http.StatusInternalServerError {
+		} else {
 			h.emitAudit(c, "ERROR", "internal error")
 		}
 		c.JSON(status, gin.H{"error": "message not found"})
@@ -357,13 +563,23 @@ func (h *ChatHandler) DeleteMessageForAll(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "message does not belong to chat"})
 		return
 	}
+	if msg.DeletedForAll {
+		c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+		return
+	}
 	if msg.SenderID != userID {
-		h.emitAudit(c, "ERROR", "not allowed to delete for all")
-		c.JSON(http.StatusForbidden, gin.H{"error": "only sender can delete for all"})
+		h.emitAudit(c, "ERROR", "not allowed to edit")
+		c.JSON(http.StatusForbidden, gin.H{"error": "only sender can edit"})
+		return
+	}
+	if time.Since(msg.CreatedAt) > h.editWindow {
+		h.emitAudit(c, "ERROR", "edit window expired")
+		c.JSON(http.StatusForbidden, gin.H{"error": "edit window expired"})
 		return
 	}
 
-	if err := h.messageRepo.DeleteMessageForAll(c.Request.Context(), messageID, userID); err != nil {
+	edited, err := h.messageRepo.EditMessage(requestContext(c), messageID, userID, req.Content)
+	if err != nil {
 		status := http.StatusInternalServerError
 		if errors.Is(err, repositories.ErrMessageNotFound) {
 			status = http.StatusNotFound
@@ -373,15 +589,148 @@ func (h *ChatHandler) DeleteMessageForAll(c *gin.Context) {
 		} else {
 			h.emitAudit(c, "ERROR", "internal error")
 		}
-		c.JSON(status, gin.H{"error": "could not delete message"})
+		c.JSON(status, gin.H{"error": "could not edit message"})
 		return
 	}
 
-	h.hub.BroadcastDeletion(chatID, messageID)
-	h.emitAudit(c, "INFO", "Message deleted for all")
+	h.hub.BroadcastChatEdit(chatID, edited)
+	h.emitAudit(c, "INFO", "Message edited")
+	c.JSON(http.StatusOK, edited)
+}
+
+// AddReaction handles PUT /chats/:chat_id/messages/:message_id/reactions/:emoji.
+func (h *ChatHandler) AddReaction(c *gin.Context) {
+	h.setReaction(c, true)
+}
+
+// RemoveReaction handles DELETE /chats/:chat_id/messages/:message_id/reactions/:emoji.
+func (h *ChatHandler) RemoveReaction(c *gin.Context) {
+	h.setReaction(c, false)
+}
+
+func (h *ChatHandler) setReaction(c *gin.Context, add bool) {
+	chatID, messageID, ok := parseIDs(c)
+	if !ok {
+		return
+	}
+	emoji, ok := parseEmoji(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt("userID")
+	chat, err := h.participantChat(c.Request.Context(), userID, chatID)
+	if err == nil {
+		err = h.ensureOpen(c.Request.Context(), chat, userID)
+	}
+	if err != nil {
+		respondError(c, err, h.emitAudit)
+		return
+	}
+
+	msg, err := h.messageRepo.GetMessage(c.Request.Context(), messageID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repositories.ErrMessageNotFound) {
+			status = http.StatusNotFound
+		}
+		if status == http.StatusNotFound {
+			h.emitAudit(c, "ERROR", "message not found")
+		} else {
+			h.emitAudit(c, "ERROR", "internal error")
+		}
+		c.JSON(status, gin.H{"error": "message not found"})
+		return
+	}
+	if msg.ChatID != chatID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "message does not belong to chat"})
+		return
+	}
+	if msg.DeletedForAll {
+		h.emitAudit(c, "ERROR", "message not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+		return
+	}
+
+	var changed bool
+	if add {
+		changed, err = h.messageRepo.AddReaction(c.Request.Context(), messageID, userID, emoji)
+	} else {
+		changed, err = h.messageRepo.RemoveReaction(c.Request.Context(), messageID, userID, emoji)
+	}
+	if err != nil {
+		h.emitAudit(c, "ERROR", "internal error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update reaction"})
+		return
+	}
+
+	if changed {
+		h.hub.BroadcastChatReaction(chatID, add, models.Reaction{MessageID: messageID, UserID: userID, Emoji: emoji})
+	}
+	if add {
+		h.emitAudit(c, "INFO", "Reaction added")
+	} else {
+		h.emitAudit(c, "INFO", "Reaction removed")
+	}
 	c.Status(http.StatusNoContent)
 }
 
+// MarkChatRead handles POST /chats/:chat_id/read, advancing the caller's read marker.
+// Without a message_id the chat is marked read up to its latest message.
+func (h *ChatHandler) MarkChatRead(c *gin.Context) {
+	chatID, err := strconv.Atoi(c.Param("chat_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return
+	}
+
+	messageID, ok := bindReadMarker(c)
+	if !ok {
+		return
+	}
+
+	lastRead, err := h.markRead(c.Request.Context(), c.GetInt("userID"), chatID, messageID)
+	if err != nil {
+		respondError(c, err, h.emitAudit)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"last_read_message_id": lastRead})
+}
+
+// markRead advances userID's read marker (0 means the latest message) and broadcasts it when it moved.
+func (h *ChatHandler) markRead(ctx context.Context, userID, chatID, messageID int) (int, error) {
+	member, err := h.chatRepo.IsParticipant(ctx, chatID, userID)
+	if err != nil {
+		return 0, newRequestError(http.StatusInternalServerError, "failed to verify membership", "")
+	}
+	if !member {
+		return 0, newRequestError(http.StatusForbidden, "not a chat member", "")
+	}
+
+	if messageID > 0 {
+		msg, err := h.messageRepo.GetMessage(ctx, messageID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrMessageNotFound) {
+				return 0, newRequestError(http.StatusNotFound, "message not found", "")
+			}
+			return 0, newRequestError(http.StatusInternalServerError, "message not found", "")
+		}
+		if msg.ChatID != chatID {
+			return 0, newRequestError(http.StatusBadRequest, "message does not belong to chat", "")
+		}
+	}
+
+	lastRead, advanced, err := h.chatRepo.MarkRead(ctx, chatID, userID, messageID)
+	if err != nil {
+		return 0, newRequestError(http.StatusInternalServerError, "could not mark chat read", "")
+	}
+
+	if advanced {
+		h.hub.BroadcastChatRead(chatID, userID, lastRead)
+	}
+	return lastRead, nil
+}
+
 // DeleteChatForMe hides the chat for the requester.
 func (h *ChatHandler) DeleteChatForMe(c *gin.Context) {
 	chatID, err := strconv.Atoi(c.Param("chat_id"))
@@ -413,6 +762,21 @@ func (h *ChatHandler) DeleteChatForMe(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// validateReplyTarget ensures a quoted message exists in the same chat.
+func (h *ChatHandler) validateReplyTarget(ctx context.Context, chatID, messageID int) error {
+	quoted, err := h.messageRepo.GetMessage(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMessageNotFound) {
+			return newRequestError(http.StatusBadRequest, "reply target not found", "reply target not found")
+		}
+		return newRequestError(http.StatusInternalServerError, "failed to load reply target", "internal error")
+	}
+	if quoted.ChatID != chatID {
+		return newRequestError(http.StatusBadRequest, "reply target does not belong to chat", "reply target not in chat")
+	}
+	return nil
+}
+
 func (h *ChatHandler) emitAudit(c *gin.Context, level, text string) {
 	if h.audit == nil {
 		return
@@ -437,3 +801,11 @@ func parseIDs(c *gin.Context) (int, int, bool) {
 func isChatParticipant(chat models.Chat, userID int) bool {
 	return chat.User1ID == userID || chat.User2ID == userID
 }
+
+// chatPeer returns the participant of chat other than userID.
+func chatPeer(chat models.Chat, userID int) int {
+	if chat.User1ID == userID {
+		return chat.User2ID
+	}
+	return chat.User1ID
+}
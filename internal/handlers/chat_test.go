@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -14,6 +16,7 @@ import (
 
 	"chat-service/internal/mocks"
 	"chat-service/internal/models"
+	"chat-service/internal/repositories"
 	"chat-service/internal/telemetry"
 	"chat-service/internal/ws"
 	userpb "chat-service/pb/user"
@@ -30,19 +33,38 @@ func setupChatRouter(handler *ChatHandler) *gin.Engine {
 	r.POST("/chats/start", handler.StartChat)
 	r.GET("/chats/:chat_id/messages", handler.GetChatMessages)
 	r.POST("/chats/:chat_id/messages", handler.PostChatMessage)
+	r.PATCH("/chats/:chat_id/messages/:message_id", handler.EditMessage)
+	r.PUT("/chats/:chat_id/messages/:message_id/reactions/:emoji", handler.AddReaction)
+	r.DELETE("/chats/:chat_id/messages/:message_id/reactions/:emoji", handler.RemoveReaction)
 	r.DELETE("/chats/:chat_id/messages/:message_id/all", handler.DeleteMessageForAll)
+	r.POST("/chats/:chat_id/read", handler.MarkChatRead)
 	return r
 }
 
+// friendClient returns a user client reporting users 1 and 2 as friends.
+func friendClient() *mocks.UserClientMock {
+	userClient := new(mocks.UserClientMock)
+	userClient.On("AreFriends", mock.Anything, 1, 2).Return(true, nil)
+	return userClient
+}
+
+// noBlocks returns a block repository in which nobody blocked anyone.
+func noBlocks() *mocks.BlockRepositoryMock {
+	blockRepo := new(mocks.BlockRepositoryMock)
+	blockRepo.On("IsBlocked", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+	return blockRepo
+}
+
 func TestListChatsSuccess(t *testing.T) {
 	chatRepo := new(mocks.ChatRepositoryMock)
 	groupRepo := new(mocks.GroupRepositoryMock)
 	userClient := new(mocks.UserClientMock)
-	handler := NewChatHandler(chatRepo, nil, userClient, groupRepo, nil, nil)
+	handler := NewChatHandler(chatRepo, nil, userClient, groupRepo, nil, nil, nil)
 	router := setupChatRouter(handler)
 
-	chatRepo.On("ListChats", mock.Anything, 1).Return([]models.ChatSummary{{ChatID: 3, FriendID: 2}}, nil).Once()
-	groupRepo.On("ListGroupsForUser", mock.Anything, 1).Return([]models.Group{{ID: 7, Name: "g"}}, nil).Once()
+	reason := models.ChatReadOnlyUnfriended
+	chatRepo.On("ListChats", mock.Anything, 1).Return([]models.ChatSummary{{ChatID: 3, FriendID: 2, ReadOnlyReason: &reason}}, nil).Once()
+	groupRepo.On("ListGroupSummaries", mock.Anything, 1).Return([]models.GroupSummary{{Group: models.Group{ID: 7, Name: "g"}, UnreadCount: 4}}, nil).Once()
 	userClient.On("BulkUsers", mock.Anything, []int{2}).Return([]*userpb.GetUserResponse{{Id: 2, Username: "bob"}}, nil).Once()
 
 	req := httptest.NewRequest(http.MethodGet, "/chats", nil)
@@ -50,17 +72,102 @@ func TestListChatsSuccess(t *testing.T) {
 	router.ServeHTTP(rec, req)
 
 	require.Equal(t, http.StatusOK, rec.Code)
-	var resp map[string]any
+	var resp struct {
+		Chats []map[string]any `json:"chats"`
+	}
 	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Chats, 2)
+	for _, chat := range resp.Chats {
+		if chat["type"] == "private" {
+			assert.Equal(t, "unfriended", chat["read_only_reason"])
+		} else {
+			assert.NotContains(t, chat, "read_only_reason")
+		}
+	}
 
 	chatRepo.AssertExpectations(t)
 	groupRepo.AssertExpectations(t)
 	userClient.AssertExpectations(t)
 }
 
+func TestListChatsOrderedByActivity(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	groupRepo := new(mocks.GroupRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	handler := NewChatHandler(chatRepo, nil, userClient, groupRepo, nil, nil, nil)
+	router := setupChatRouter(handler)
+
+	now := time.Now().UTC()
+	chatRepo.On("ListChats", mock.Anything, 1).Return([]models.ChatSummary{
+		{ChatID: 3, FriendID: 2, LastActivityAt: now.Add(-time.Hour)},
+	}, nil).Once()
+	groupRepo.On("ListGroupSummaries", mock.Anything, 1).Return([]models.GroupSummary{
+		{
+			Group:          models.Group{ID: 7, Name: "g"},
+			LastMessage:    &models.MessagePreview{ID: 11, SenderID: 4, Snippet: "latest", CreatedAt: now},
+			LastActivityAt: now,
+		},
+	}, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{2, 4}).Return([]*userpb.GetUserResponse{{Id: 2, Username: "bob"}, {Id: 4, Username: "eve"}}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/chats", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Chats []struct {
+			Type        string `json:"type"`
+			LastMessage *struct {
+				Snippet        string `json:"snippet"`
+				SenderUsername string `json:"sender_username"`
+			} `json:"last_message"`
+		} `json:"chats"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Chats, 2)
+	assert.Equal(t, "group", resp.Chats[0].Type)
+	require.NotNil(t, resp.Chats[0].LastMessage)
+	assert.Equal(t, "eve", resp.Chats[0].LastMessage.SenderUsername)
+	assert.Equal(t, "private", resp.Chats[1].Type)
+	assert.Nil(t, resp.Chats[1].LastMessage)
+}
+
+func TestListChatsPrivatePreviewSenderUsername(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	groupRepo := new(mocks.GroupRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	handler := NewChatHandler(chatRepo, nil, userClient, groupRepo, nil, nil, nil)
+	router := setupChatRouter(handler)
+
+	chatRepo.On("ListChats", mock.Anything, 1).Return([]models.ChatSummary{
+		{ChatID: 3, FriendID: 2, LastMessage: &models.MessagePreview{ID: 9, SenderID: 1, Snippet: "hi"}},
+	}, nil).Once()
+	groupRepo.On("ListGroupSummaries", mock.Anything, 1).Return([]models.GroupSummary{}, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{2, 1}).Return([]*userpb.GetUserResponse{{Id: 2, Username: "bob"}, {Id: 1, Username: "alice"}}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/chats", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Chats []struct {
+			LastMessage *struct {
+				SenderUsername string `json:"sender_username"`
+			} `json:"last_message"`
+		} `json:"chats"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Chats, 1)
+	require.NotNil(t, resp.Chats[0].LastMessage)
+	assert.Equal(t, "alice", resp.Chats[0].LastMessage.SenderUsername)
+	userClient.AssertExpectations(t)
+}
+
 func TestListChatsRepoError(t *testing.T) {
 	chatRepo := new(mocks.ChatRepositoryMock)
-	handler := NewChatHandler(chatRepo, nil, new(mocks.UserClientMock), new(mocks.GroupRepositoryMock), nil, nil)
+	handler := NewChatHandler(chatRepo, nil, new(mocks.UserClientMock), new(mocks.GroupRepositoryMock), nil, nil, nil)
 	router := setupChatRouter(handler)
 
 	chatRepo.On("ListChats", mock.Anything, 1).Return(([]models.ChatSummary)(nil), assert.AnError).Once()
@@ -78,7 +185,7 @@ func TestStartChatSuccess(t *testing.T) {
 	userClient := new(mocks.UserClientMock)
 	publisher := new(mocks.PublisherMock)
 	emitter := telemetry.NewAuditEmitter(publisher, "chat-service.audit", "chat-service", "local")
-	handler := NewChatHandler(chatRepo, nil, userClient, new(mocks.GroupRepositoryMock), nil, emitter)
+	handler := NewChatHandler(chatRepo, nil, userClient, new(mocks.GroupRepositoryMock), noBlocks(), ws.NewHub(), emitter)
 	router := setupChatRouter(handler)
 
 	body := bytes.NewBufferString(`{"friend_id":2}`)
@@ -103,7 +210,7 @@ func TestStartChatSuccess(t *testing.T) {
 
 func TestStartChatFriendCheckError(t *testing.T) {
 	userClient := new(mocks.UserClientMock)
-	handler := NewChatHandler(new(mocks.ChatRepositoryMock), nil, userClient, new(mocks.GroupRepositoryMock), nil, nil)
+	handler := NewChatHandler(new(mocks.ChatRepositoryMock), nil, userClient, new(mocks.GroupRepositoryMock), nil, nil, nil)
 	router := setupChatRouter(handler)
 
 	userClient.On("AreFriends", mock.Anything, 1, 5).Return(false, assert.AnError).Once()
@@ -116,14 +223,31 @@ func TestStartChatFriendCheckError(t *testing.T) {
 	userClient.AssertExpectations(t)
 }
 
+func TestStartChatBlocked(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	blockRepo := new(mocks.BlockRepositoryMock)
+	handler := NewChatHandler(chatRepo, nil, friendClient(), new(mocks.GroupRepositoryMock), blockRepo, ws.NewHub(), nil)
+	router := setupChatRouter(handler)
+
+	blockRepo.On("IsBlocked", mock.Anything, 1, 2).Return(true, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/start", bytes.NewBufferString(`{"friend_id":2}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.JSONEq(t, `{"error":"user is blocked"}`, rec.Body.String())
+	chatRepo.AssertNotCalled(t, "CreateOrGetChat", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestGetChatMessagesSuccess(t *testing.T) {
 	chatRepo := new(mocks.ChatRepositoryMock)
 	messageRepo := new(mocks.MessageRepositoryMock)
 	userClient := new(mocks.UserClientMock)
-	handler := NewChatHandler(chatRepo, messageRepo, userClient, nil, nil, nil)
+	handler := NewChatHandler(chatRepo, messageRepo, userClient, nil, nil, nil, nil)
 	router := setupChatRouter(handler)
 
-	messageRepo.On("GetChatMessagesForUser", mock.Anything, 5, 1).Return([]models.Message{{ID: 1, ChatID: 5, SenderID: 1}}, nil).Once()
+	messageRepo.On("GetChatMessagesForUser", mock.Anything, 5, 1, repositories.PageRequest{}).Return([]models.Message{{ID: 1, ChatID: 5, SenderID: 1}}, 0, nil).Once()
 	chatRepo.On("IsParticipant", mock.Anything, 5, 1).Return(true, nil).Once()
 	userClient.On("BulkUsers", mock.Anything, []int{1}).Return([]*userpb.GetUserResponse{{Id: 1, Username: "me"}}, nil).Once()
 
@@ -137,8 +261,52 @@ func TestGetChatMessagesSuccess(t *testing.T) {
 	userClient.AssertExpectations(t)
 }
 
+func TestGetChatMessagesWithCursor(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	handler := NewChatHandler(chatRepo, messageRepo, userClient, nil, nil, nil, nil)
+	router := setupChatRouter(handler)
+
+	chatRepo.On("IsParticipant", mock.Anything, 5, 1).Return(true, nil).Once()
+	messageRepo.On("GetChatMessagesForUser", mock.Anything, 5, 1, repositories.PageRequest{Before: 40, Limit: 2}).
+		Return([]models.Message{{ID: 38, ChatID: 5, SenderID: 2}, {ID: 39, ChatID: 5, SenderID: 2}}, 38, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{2}).Return([]*userpb.GetUserResponse{{Id: 2, Username: "bob"}}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/5/messages?before=40&limit=2", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Messages   []models.Message `json:"messages"`
+		NextCursor *int             `json:"next_cursor"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Messages, 2)
+	require.NotNil(t, resp.NextCursor)
+	assert.Equal(t, 38, *resp.NextCursor)
+	messageRepo.AssertExpectations(t)
+}
+
+func TestGetChatMessagesInvalidPagination(t *testing.T) {
+	for _, query := range []string{"before=1&after=2", "limit=0", "limit=1000", "before=abc"} {
+		chatRepo := new(mocks.ChatRepositoryMock)
+		handler := NewChatHandler(chatRepo, new(mocks.MessageRepositoryMock), new(mocks.UserClientMock), nil, nil, nil, nil)
+		router := setupChatRouter(handler)
+
+		chatRepo.On("IsParticipant", mock.Anything, 5, 1).Return(true, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/chats/5/messages?"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
 func TestGetChatMessagesInvalidID(t *testing.T) {
-	handler := NewChatHandler(new(mocks.ChatRepositoryMock), new(mocks.MessageRepositoryMock), new(mocks.UserClientMock), nil, nil, nil)
+	handler := NewChatHandler(new(mocks.ChatRepositoryMock), new(mocks.MessageRepositoryMock), new(mocks.UserClientMock), nil, nil, nil, nil)
 	router := setupChatRouter(handler)
 
 	req := httptest.NewRequest(http.MethodGet, "/chats/abc/messages", nil)
@@ -154,11 +322,11 @@ func TestPostChatMessageSuccess(t *testing.T) {
 	hub := ws.NewHub()
 	publisher := new(mocks.PublisherMock)
 	emitter := telemetry.NewAuditEmitter(publisher, "chat-service.audit", "chat-service", "local")
-	handler := NewChatHandler(chatRepo, messageRepo, nil, nil, hub, emitter)
+	handler := NewChatHandler(chatRepo, messageRepo, friendClient(), nil, noBlocks(), hub, emitter)
 	router := setupChatRouter(handler)
 
 	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
-	messageRepo.On("CreateChatMessage", mock.Anything, 5, 1, "hi").Return(models.Message{ID: 7, ChatID: 5, SenderID: 1, Content: "hi"}, nil).Once()
+	messageRepo.On("CreateChatMessage", mock.Anything, 5, 1, "hi", (*int)(nil), ([]int)(nil)).Return(models.Message{ID: 7, ChatID: 5, SenderID: 1, Content: "hi"}, nil).Once()
 	chatRepo.On("UnhideChatForUser", mock.Anything, 5, 1).Return(nil).Once()
 	chatRepo.On("UnhideChatForUser", mock.Anything, 5, 2).Return(nil).Once()
 	publisher.On("Publish", mock.Anything, "chat-service.audit", mock.MatchedBy(func(event any) bool {
@@ -178,8 +346,185 @@ func TestPostChatMessageSuccess(t *testing.T) {
 	publisher.AssertExpectations(t)
 }
 
+func TestPostChatMessagePassesRequestIDToRepository(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	router := setupChatRouter(NewChatHandler(chatRepo, messageRepo, friendClient(), nil, noBlocks(), ws.NewHub(), nil))
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	messageRepo.On("CreateChatMessage", mock.MatchedBy(func(ctx context.Context) bool {
+		return telemetry.RequestIDFromContext(ctx) == "req-789"
+	}), 5, 1, "hi", (*int)(nil), ([]int)(nil)).Return(models.Message{ID: 7, ChatID: 5, SenderID: 1, Content: "hi"}, nil).Once()
+	chatRepo.On("UnhideChatForUser", mock.Anything, 5, mock.Anything).Return(nil).Twice()
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/5/messages", bytes.NewBufferString(`{"content":"hi"}`))
+	req.Header.Set("X-Request-ID", "req-789")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	messageRepo.AssertExpectations(t)
+}
+
+func TestPostChatMessageAttachmentsOnly(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	router := setupChatRouter(NewChatHandler(chatRepo, messageRepo, friendClient(), nil, noBlocks(), ws.NewHub(), nil))
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	messageRepo.On("CreateChatMessage", mock.Anything, 5, 1, "", (*int)(nil), []int{4, 6}).Return(models.Message{
+		ID: 7, ChatID: 5, SenderID: 1,
+		Attachments: []models.Attachment{{ID: 4, FileName: "a.png", ContentType: "image/png", Size: 3, URL: "/attachments/4"}},
+	}, nil).Once()
+	chatRepo.On("UnhideChatForUser", mock.Anything, 5, mock.Anything).Return(nil).Twice()
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/5/messages", bytes.NewBufferString(`{"attachment_ids":[4,6,4]}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, []any{map[string]any{
+		"id": float64(4), "file_name": "a.png", "content_type": "image/png", "size": float64(3),
+		"created_at": "0001-01-01T00:00:00Z", "url": "/attachments/4",
+	}}, body["attachments"])
+	messageRepo.AssertExpectations(t)
+}
+
+func TestPostChatMessageRequiresContentOrAttachments(t *testing.T) {
+	messageRepo := new(mocks.MessageRepositoryMock)
+	router := setupChatRouter(NewChatHandler(new(mocks.ChatRepositoryMock), messageRepo, nil, nil, nil, ws.NewHub(), nil))
+
+	for _, body := range []string{`{}`, `{"content":"","attachment_ids":[]}`, `{"attachment_ids":[0]}`} {
+		req := httptest.NewRequest(http.MethodPost, "/chats/5/messages", bytes.NewBufferString(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	messageRepo.AssertNotCalled(t, "CreateChatMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostChatMessageAttachmentUnavailable(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	router := setupChatRouter(NewChatHandler(chatRepo, messageRepo, friendClient(), nil, noBlocks(), ws.NewHub(), nil))
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	messageRepo.On("CreateChatMessage", mock.Anything, 5, 1, "see", (*int)(nil), []int{9}).Return(nil, repositories.ErrAttachmentUnavailable).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/5/messages", bytes.NewBufferString(`{"content":"see","attachment_ids":[9]}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.JSONEq(t, `{"error":"attachment unavailable"}`, rec.Body.String())
+}
+
+func TestPostChatMessageReply(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	handler := NewChatHandler(chatRepo, messageRepo, friendClient(), nil, noBlocks(), ws.NewHub(), nil)
+	router := setupChatRouter(handler)
+
+	replyTo := 4
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	messageRepo.On("GetMessage", mock.Anything, 4).Return(models.Message{ID: 4, ChatID: 5, SenderID: 2, Content: "question?"}, nil).Once()
+	messageRepo.On("CreateChatMessage", mock.Anything, 5, 1, "answer", &replyTo, ([]int)(nil)).Return(models.Message{
+		ID: 8, ChatID: 5, SenderID: 1, Content: "answer", ReplyToMessageID: &replyTo,
+		ReplyTo: models.NewQuotedMessage(4, 2, "question?", false),
+	}, nil).Once()
+	chatRepo.On("UnhideChatForUser", mock.Anything, 5, 1).Return(nil).Once()
+	chatRepo.On("UnhideChatForUser", mock.Anything, 5, 2).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/5/messages", bytes.NewBufferString(`{"content":"answer","reply_to_message_id":4}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var resp models.Message
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.NotNil(t, resp.ReplyTo)
+	assert.Equal(t, "question?", resp.ReplyTo.Content)
+	messageRepo.AssertExpectations(t)
+}
+
+func TestPostChatMessageReplyToOtherChat(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	handler := NewChatHandler(chatRepo, messageRepo, friendClient(), nil, noBlocks(), ws.NewHub(), nil)
+	router := setupChatRouter(handler)
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	messageRepo.On("GetMessage", mock.Anything, 4).Return(models.Message{ID: 4, ChatID: 6, SenderID: 3}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/5/messages", bytes.NewBufferString(`{"content":"answer","reply_to_message_id":4}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	messageRepo.AssertNotCalled(t, "CreateChatMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostChatMessageReadOnlyChat(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	router := setupChatRouter(NewChatHandler(chatRepo, messageRepo, userClient, nil, nil, ws.NewHub(), nil))
+
+	reason := models.ChatReadOnlyAccountDeleted
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2, ReadOnlyReason: &reason}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/5/messages", bytes.NewBufferString(`{"content":"hi"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.JSONEq(t, `{"error":"chat is read-only"}`, rec.Body.String())
+	userClient.AssertNotCalled(t, "AreFriends", mock.Anything, mock.Anything, mock.Anything)
+	messageRepo.AssertNotCalled(t, "CreateChatMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostChatMessageRequiresFriendship(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	router := setupChatRouter(NewChatHandler(chatRepo, messageRepo, userClient, nil, noBlocks(), ws.NewHub(), nil))
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	userClient.On("AreFriends", mock.Anything, 1, 2).Return(false, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/5/messages", bytes.NewBufferString(`{"content":"hi"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.JSONEq(t, `{"error":"users are not friends"}`, rec.Body.String())
+	messageRepo.AssertNotCalled(t, "CreateChatMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostChatMessageBlocked(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	blockRepo := new(mocks.BlockRepositoryMock)
+	router := setupChatRouter(NewChatHandler(chatRepo, messageRepo, userClient, nil, blockRepo, ws.NewHub(), nil))
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	blockRepo.On("IsBlocked", mock.Anything, 1, 2).Return(true, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/5/messages", bytes.NewBufferString(`{"content":"hi"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.JSONEq(t, `{"error":"user is blocked"}`, rec.Body.String())
+	userClient.AssertNotCalled(t, "AreFriends", mock.Anything, mock.Anything, mock.Anything)
+	messageRepo.AssertNotCalled(t, "CreateChatMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestPostChatMessageInvalidID(t *testing.T) {
-	handler := NewChatHandler(new(mocks.ChatRepositoryMock), new(mocks.MessageRepositoryMock), nil, nil, ws.NewHub(), nil)
+	handler := NewChatHandler(new(mocks.ChatRepositoryMock), new(mocks.MessageRepositoryMock), nil, nil, nil, ws.NewHub(), nil)
 	router := setupChatRouter(handler)
 
 	req := httptest.NewRequest(http.MethodPost, "/chats/bad/messages", bytes.NewBufferString(`{"content":"hi"}`))
@@ -188,3 +533,198 @@ func TestPostChatMessageInvalidID(t *testing.T) {
 
 	require.Equal(t, http.StatusBadRequest, rec.Code)
 }
+
+func TestChatSocketActionsSendMessageNotParticipant(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	handler := NewChatHandler(chatRepo, messageRepo, nil, nil, nil, ws.NewHub(), nil)
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 2, User2ID: 3}, nil).Once()
+
+	_, err := handler.SocketActions().SendMessage(context.Background(), 1, 5, "hi", nil, nil)
+
+	var statusErr ws.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusForbidden, statusErr.StatusCode())
+	assert.Equal(t, "not a chat member", statusErr.Error())
+	messageRepo.AssertNotCalled(t, "CreateChatMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEditMessageSuccess(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	handler := NewChatHandler(chatRepo, messageRepo, nil, nil, noBlocks(), ws.NewHub(), nil)
+	router := setupChatRouter(handler)
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	messageRepo.On("GetMessage", mock.Anything, 7).Return(models.Message{ID: 7, ChatID: 5, SenderID: 1, Content: "helo", CreatedAt: time.Now()}, nil).Once()
+	messageRepo.On("EditMessage", mock.Anything, 7, 1, "hello").Return(models.Message{ID: 7, ChatID: 5, SenderID: 1, Content: "hello"}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPatch, "/chats/5/messages/7", bytes.NewBufferString(`{"content":"hello"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	chatRepo.AssertExpectations(t)
+	messageRepo.AssertExpectations(t)
+}
+
+func TestEditMessageWindowExpired(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	handler := NewChatHandler(chatRepo, messageRepo, nil, nil, noBlocks(), ws.NewHub(), nil)
+	handler.SetEditWindow(time.Minute)
+	router := setupChatRouter(handler)
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	messageRepo.On("GetMessage", mock.Anything, 7).Return(models.Message{ID: 7, ChatID: 5, SenderID: 1, CreatedAt: time.Now().Add(-time.Hour)}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPatch, "/chats/5/messages/7", bytes.NewBufferString(`{"content":"hello"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	messageRepo.AssertNotCalled(t, "EditMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEditMessageNotSender(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	handler := NewChatHandler(chatRepo, messageRepo, nil, nil, noBlocks(), ws.NewHub(), nil)
+	router := setupChatRouter(handler)
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	messageRepo.On("GetMessage", mock.Anything, 7).Return(models.Message{ID: 7, ChatID: 5, SenderID: 2, CreatedAt: time.Now()}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPatch, "/chats/5/messages/7", bytes.NewBufferString(`{"content":"hello"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAddReactionSuccess(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	publisher := new(mocks.PublisherMock)
+	emitter := telemetry.NewAuditEmitter(publisher, "chat-service.audit", "chat-service", "local")
+	handler := NewChatHandler(chatRepo, messageRepo, nil, nil, noBlocks(), ws.NewHub(), emitter)
+	router := setupChatRouter(handler)
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	messageRepo.On("GetMessage", mock.Anything, 7).Return(models.Message{ID: 7, ChatID: 5, SenderID: 2}, nil).Once()
+	messageRepo.On("AddReaction", mock.Anything, 7, 1, "👍").Return(true, nil).Once()
+	publisher.On("Publish", mock.Anything, "chat-service.audit", mock.MatchedBy(func(event any) bool {
+		envelope, ok := event.(telemetry.AuditEnvelope)
+		return ok && envelope.Payload.Level == "INFO" && envelope.Payload.Text == "Reaction added"
+	})).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPut, "/chats/5/messages/7/reactions/%F0%9F%91%8D", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	chatRepo.AssertExpectations(t)
+	messageRepo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
+
+func TestEditMessageReadOnlyChat(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	handler := NewChatHandler(chatRepo, messageRepo, nil, nil, noBlocks(), ws.NewHub(), nil)
+	router := setupChatRouter(handler)
+
+	reason := models.ChatReadOnlyUnfriended
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2, ReadOnlyReason: &reason}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPatch, "/chats/5/messages/7", bytes.NewBufferString(`{"content":"hello"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	assert.JSONEq(t, `{"error":"chat is read-only"}`, rec.Body.String())
+	messageRepo.AssertNotCalled(t, "EditMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAddReactionReadOnlyChat(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	handler := NewChatHandler(chatRepo, messageRepo, nil, nil, noBlocks(), ws.NewHub(), nil)
+	router := setupChatRouter(handler)
+
+	reason := models.ChatReadOnlyUnfriended
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2, ReadOnlyReason: &reason}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPut, "/chats/5/messages/7/reactions/%F0%9F%91%8D", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	assert.JSONEq(t, `{"error":"chat is read-only"}`, rec.Body.String())
+	messageRepo.AssertNotCalled(t, "AddReaction", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRemoveReactionBlocked(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	blockRepo := new(mocks.BlockRepositoryMock)
+	handler := NewChatHandler(chatRepo, messageRepo, nil, nil, blockRepo, ws.NewHub(), nil)
+	router := setupChatRouter(handler)
+
+	chatRepo.On("GetChat", mock.Anything, 5).Return(models.Chat{ID: 5, User1ID: 1, User2ID: 2}, nil).Once()
+	blockRepo.On("IsBlocked", mock.Anything, 1, 2).Return(true, nil).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/chats/5/messages/7/reactions/%F0%9F%91%8D", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	assert.JSONEq(t, `{"error":"user is blocked"}`, rec.Body.String())
+	messageRepo.AssertNotCalled(t, "RemoveReaction", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRemoveReactionInvalidEmoji(t *testing.T) {
+	handler := NewChatHandler(new(mocks.ChatRepositoryMock), new(mocks.MessageRepositoryMock), nil, nil, nil, ws.NewHub(), nil)
+	router := setupChatRouter(handler)
+
+	req := httptest.NewRequest(http.MethodDelete, "/chats/5/messages/7/reactions/%20%20", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestMarkChatReadLatest(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	handler := NewChatHandler(chatRepo, new(mocks.MessageRepositoryMock), nil, nil, nil, ws.NewHub(), nil)
+	router := setupChatRouter(handler)
+
+	chatRepo.On("IsParticipant", mock.Anything, 5, 1).Return(true, nil).Once()
+	chatRepo.On("MarkRead", mock.Anything, 5, 1, 0).Return(42, true, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/5/read", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"last_read_message_id":42}`, rec.Body.String())
+	chatRepo.AssertExpectations(t)
+}
+
+func TestMarkChatReadForeignMessage(t *testing.T) {
+	chatRepo := new(mocks.ChatRepositoryMock)
+	messageRepo := new(mocks.MessageRepositoryMock)
+	handler := NewChatHandler(chatRepo, messageRepo, nil, nil, nil, ws.NewHub(), nil)
+	router := setupChatRouter(handler)
+
+	chatRepo.On("IsParticipant", mock.Anything, 5, 1).Return(true, nil).Once()
+	messageRepo.On("GetMessage", mock.Anything, 9).Return(models.Message{ID: 9, ChatID: 6}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/5/read", bytes.NewBufferString(`{"message_id":9}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	chatRepo.AssertNotCalled(t, "MarkRead", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -20,6 +22,7 @@ type GroupHandler struct {
 	userClient  userClient
 	hub         *ws.Hub
 	audit       *telemetry.AuditEmitter
+	editWindow  time.Duration
 }
 
 // NewGroupHandler constructs a GroupHandler.
@@ -30,9 +33,15 @@ func NewGroupHandler(groupRepo repositories.GroupRepository, messageRepo reposit
 		userClient:  userClient,
 		hub:         hub,
 		audit:       audit,
+		editWindow:  DefaultEditWindow,
 	}
 }
 
+// SetEditWindow overrides how long a group message stays editable after it was sent.
+func (h *GroupHandler) SetEditWindow(window time.Duration) {
+	h.editWindow = window
+}
+
 // CreateGroup handles POST /groups.
 func (h *GroupHandler) CreateGroup(c *gin.Context) {
 	userID := c.GetInt("userID")
@@ -47,6 +56,12 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 		return
 	}
 
+	name, msg := normalizeGroupName(req.Name)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
 	// Validate members exist via user-service
 	if len(req.MemberIDs) > 0 {
 		if _, err := h.userClient.BulkUsers(c.Request.Context(), req.MemberIDs); err != nil {
@@ -55,13 +70,14 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 		}
 	}
 
-	group, err := h.groupRepo.CreateGroup(c.Request.Context(), userID, req.Name, req.MemberIDs)
+	group, err := h.groupRepo.CreateGroup(requestContext(c), userID, name, req.MemberIDs)
 	if err != nil {
 		h.emitAudit(c, "ERROR", "internal error")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create group"})
 		return
 	}
 
+	h.hub.SubscribeGroup(group.ID, append([]int{userID}, req.MemberIDs...)...)
 	h.emitAudit(c, "INFO", "Group created")
 	c.JSON(http.StatusCreated, gin.H{"group_id": group.ID})
 }
@@ -98,7 +114,12 @@ func (h *GroupHandler) GetGroupMessages(c *gin.Context) {
 		return
 	}
 
-	msgs, err := h.messageRepo.ListGroupMessages(c.Request.Context(), groupID)
+	page, ok := parsePageRequest(c)
+	if !ok {
+		return
+	}
+
+	msgs, cursor, err := h.messageRepo.ListGroupMessages(c.Request.Context(), groupID, userID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load messages"})
 		return
@@ -135,7 +156,7 @@ func (h *GroupHandler) GetGroupMessages(c *gin.Context) {
 		resp = append(resp, messageResponse{GroupMessage: m, SenderUsername: usernameByID[m.SenderID]})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"messages": resp})
+	c.JSON(http.StatusOK, gin.H{"messages": resp, "next_cursor": nextCursor(cursor)})
 }
 
 // PostGroupMessage persists and broadcasts a group message.
@@ -146,21 +167,10 @@ func (h *GroupHandler) PostGroupMessage(c *gin.Context) {
 		return
 	}
 
-	userID := c.GetInt("userID")
-	member, err := h.groupRepo.IsMember(c.Request.Context(), groupID, userID)
-	if err != nil {
-		h.emitAudit(c, "ERROR", "internal error")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "membership check failed"})
-		return
-	}
-	if !member {
-		h.emitAudit(c, "ERROR", "not allowed")
-		c.JSON(http.StatusForbidden, gin.H{"error": "not a member"})
-		return
-	}
-
 	var req struct {
-		Content string `json:"content" binding:"required"`
+		Content          string `json:"content"`
+		ReplyToMessageID *int   `json:"reply_to_message_id"`
+		AttachmentIDs    []int  `json:"attachment_ids"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.emitAudit(c, "ERROR", "invalid request payload")
@@ -168,25 +178,113 @@ func (h *GroupHandler) PostGroupMessage(c *gin.Context) {
 		return
 	}
 
-	msg, err := h.messageRepo.CreateGroupMessage(c.Request.Context(), groupID, userID, req.Content)
+	msg, err := h.sendMessage(requestContext(c), c.GetInt("userID"), groupID, req.Content, req.ReplyToMessageID, req.AttachmentIDs)
 	if err != nil {
-		h.emitAudit(c, "ERROR", "internal error")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store message"})
+		respondError(c, err, h.emitAudit)
 		return
 	}
 
-	h.hub.BroadcastGroupMessage(groupID, msg)
 	h.emitAudit(c, "INFO", "Group message sent")
 	c.JSON(http.StatusCreated, msg)
 }
 
-// DeleteGroupMessageForAll deletes a message for everyone when invoked by the sender.
+// sendMessage stores and broadcasts a group message on behalf of userID.
+func (h *GroupHandler) sendMessage(ctx context.Context, userID, groupID int, content string, replyToID *int, attachmentIDs []int) (models.GroupMessage, error) {
+	attachmentIDs, err := messageAttachmentIDs(content, attachmentIDs)
+	if err != nil {
+		return models.GroupMessage{}, err
+	}
+
+	member, err := h.groupRepo.IsMember(ctx, groupID, userID)
+	if err != nil {
+		return models.GroupMessage{}, newRequestError(http.StatusInternalServerError, "membership check failed", "internal error")
+	}
+	if !member {
+		return models.GroupMessage{}, newRequestError(http.StatusForbidden, "not a member", "not allowed")
+	}
+	if replyToID != nil {
+		if err := h.validateReplyTarget(ctx, groupID, *replyToID); err != nil {
+			return models.GroupMessage{}, err
+		}
+	}
+
+	msg, err := h.messageRepo.CreateGroupMessage(ctx, groupID, userID, content, replyToID, attachmentIDs)
+	if err != nil {
+		if errors.Is(err, repositories.ErrAttachmentUnavailable) {
+			return models.GroupMessage{}, newRequestError(http.StatusBadRequest, "attachment unavailable", "invalid request payload")
+		}
+		return models.GroupMessage{}, newRequestError(http.StatusInternalServerError, "failed to store message", "internal error")
+	}
+
+	h.hub.StopGroupTyping(groupID, userID)
+	h.hub.BroadcastGroupMessage(groupID, msg)
+	return msg, nil
+}
+
+// DeleteGroupMessageForAll deletes a message for everyone when invoked by the sender or a group admin.
 func (h *GroupHandler) DeleteGroupMessageForAll(c *gin.Context) {
 	groupID, messageID, ok := parseGroupIDs(c)
 	if !ok {
 		return
 	}
 
+	if err := h.deleteForAll(requestContext(c), c.GetInt("userID"), groupID, messageID); err != nil {
+		respondError(c, err, h.emitAudit)
+		return
+	}
+
+	h.emitAudit(c, "INFO", "Group message deleted for all")
+	c.Status(http.StatusNoContent)
+}
+
+// deleteForAll deletes a group message for everyone and broadcasts the deletion.
+func (h *GroupHandler) deleteForAll(ctx context.Context, userID, groupID, messageID int) error {
+	role, err := h.groupRepo.GetMemberRole(ctx, groupID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotGroupMember) {
+			return newRequestError(http.StatusForbidden, "not a member", "not allowed")
+		}
+		return newRequestError(http.StatusInternalServerError, "membership check failed", "internal error")
+	}
+
+	msg, err := h.messageRepo.GetGroupMessage(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMessageNotFound) {
+			return newRequestError(http.StatusNotFound, "message not found", "message not found")
+		}
+		return newRequestError(http.StatusInternalServerError, "message not found", "internal error")
+	}
+	if msg.GroupID != groupID {
+		return newRequestError(http.StatusBadRequest, "message does not belong to group", "")
+	}
+	if msg.Kind == models.GroupMessageKindSystem {
+		return newRequestError(http.StatusForbidden, "system messages cannot be deleted", "")
+	}
+	if msg.SenderID != userID && !roleAllows(role, actionModerateMessages) {
+		return newRequestError(http.StatusForbidden, "only sender or group admins may delete", "not allowed to delete for all")
+	}
+
+	deleted, err := h.messageRepo.DeleteForAll(ctx, messageID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMessageNotFound) {
+			return newRequestError(http.StatusNotFound, "could not delete", "message not found")
+		}
+		return newRequestError(http.StatusInternalServerError, "could not delete", "internal error")
+	}
+
+	if deleted {
+		h.hub.BroadcastGroupDeletion(groupID, messageID)
+	}
+	return nil
+}
+
+// EditGroupMessage replaces the content of a group message (sender only, within the edit window).
+func (h *GroupHandler) EditGroupMessage(c *gin.Context) {
+	groupID, messageID, ok := parseGroupIDs(c)
+	if !ok {
+		return
+	}
+
 	userID := c.GetInt("userID")
 	member, err := h.groupRepo.IsMember(c.Request.Context(), groupID, userID)
 	if err != nil {
@@ -195,11 +293,20 @@ func (h *GroupHandler) DeleteGroupMessageForAll(c *gin.Context) {
 		return
 	}
 	if !member {
-		h.emitAudit(c, "ERROR", "not allowed to delete for all")
+		h.emitAudit(c, "ERROR", "not allowed to edit")
 		c.JSON(http.StatusForbidden, gin.H{"error": "not a member"})
 		return
 	}
 
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.emitAudit(c, "ERROR", "invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	msg, err := h.messageRepo.GetGroupMessage(c.Request.Context(), messageID)
 	if err != nil {
 		status := http.StatusInternalServerError
@@ -218,13 +325,27 @@ func (h *GroupHandler) DeleteGroupMessageForAll(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "message does not belong to group"})
 		return
 	}
+	if msg.DeletedForAll {
+		c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+		return
+	}
+	if msg.Kind == models.GroupMessageKindSystem {
+		c.JSON(http.StatusForbidden, gin.H{"error": "system messages cannot be edited"})
+		return
+	}
 	if msg.SenderID != userID {
-		h.emitAudit(c, "ERROR", "not allowed to delete for all")
-		c.JSON(http.StatusForbidden, gin.H{"error": "only sender may delete"})
+		h.emitAudit(c, "ERROR", "not allowed to edit")
+		c.JSON(http.StatusForbidden, gin.H{"error": "only sender may edit"})
+		return
+	}
+	if time.Since(msg.CreatedAt) > h.editWindow {
+		h.emitAudit(c, "ERROR", "edit window expired")
+		c.JSON(http.StatusForbidden, gin.H{"error": "edit window expired"})
 		return
 	}
 
-	if err := h.messageRepo.DeleteForAll(c.Request.Context(), messageID, userID); err != nil {
+	edited, err := h.messageRepo.EditGroupMessage(requestContext(c), messageID, userID, req.Content)
+	if err != nil {
 		status := http.StatusInternalServerError
 		if errors.Is(err, repositories.ErrMessageNotFound) {
 			status = http.StatusNotFound
@@ -234,15 +355,166 @@ func (h *GroupHandler) DeleteGroupMessageForAll(c *gin.Context) {
 		} else {
 			h.emitAudit(c, "ERROR", "internal error")
 		}
-		c.JSON(status, gin.H{"error": "could not delete"})
+		c.JSON(status, gin.H{"error": "could not edit"})
 		return
 	}
 
-	h.hub.BroadcastGroupDeletion(groupID, messageID)
-	h.emitAudit(c, "INFO", "Group message deleted for all")
+	h.hub.BroadcastGroupEdit(groupID, edited)
+	h.emitAudit(c, "INFO", "Group message edited")
+	c.JSON(http.StatusOK, edited)
+}
+
+// validateReplyTarget ensures a quoted message exists in the same group.
+func (h *GroupHandler) validateReplyTarget(ctx context.Context, groupID, messageID int) error {
+	quoted, err := h.messageRepo.GetGroupMessage(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMessageNotFound) {
+			return newRequestError(http.StatusBadRequest, "reply target not found", "reply target not found")
+		}
+		return newRequestError(http.StatusInternalServerError, "failed to load reply target", "internal error")
+	}
+	if quoted.GroupID != groupID {
+		return newRequestError(http.StatusBadRequest, "reply target does not belong to group", "reply target not in group")
+	}
+	return nil
+}
+
+// AddGroupReaction handles PUT /groups/:group_id/messages/:message_id/reactions/:emoji.
+func (h *GroupHandler) AddGroupReaction(c *gin.Context) {
+	h.setReaction(c, true)
+}
+
+// RemoveGroupReaction handles DELETE /groups/:group_id/messages/:message_id/reactions/:emoji.
+func (h *GroupHandler) RemoveGroupReaction(c *gin.Context) {
+	h.setReaction(c, false)
+}
+
+func (h *GroupHandler) setReaction(c *gin.Context, add bool) {
+	groupID, messageID, ok := parseGroupIDs(c)
+	if !ok {
+		return
+	}
+	emoji, ok := parseEmoji(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt("userID")
+	member, err := h.groupRepo.IsMember(c.Request.Context(), groupID, userID)
+	if err != nil {
+		h.emitAudit(c, "ERROR", "internal error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "membership check failed"})
+		return
+	}
+	if !member {
+		h.emitAudit(c, "ERROR", "not allowed")
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a member"})
+		return
+	}
+
+	msg, err := h.messageRepo.GetGroupMessage(c.Request.Context(), messageID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repositories.ErrMessageNotFound) {
+			status = http.StatusNotFound
+		}
+		if status == http.StatusNotFound {
+			h.emitAudit(c, "ERROR", "message not found")
+		} else {
+			h.emitAudit(c, "ERROR", "internal error")
+		}
+		c.JSON(status, gin.H{"error": "message not found"})
+		return
+	}
+	if msg.GroupID != groupID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "message does not belong to group"})
+		return
+	}
+	if msg.DeletedForAll {
+		h.emitAudit(c, "ERROR", "message not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+		return
+	}
+
+	var changed bool
+	if add {
+		changed, err = h.messageRepo.AddReaction(c.Request.Context(), messageID, userID, emoji)
+	} else {
+		changed, err = h.messageRepo.RemoveReaction(c.Request.Context(), messageID, userID, emoji)
+	}
+	if err != nil {
+		h.emitAudit(c, "ERROR", "internal error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not update reaction"})
+		return
+	}
+
+	if changed {
+		h.hub.BroadcastGroupReaction(groupID, add, models.Reaction{MessageID: messageID, UserID: userID, Emoji: emoji})
+	}
+	if add {
+		h.emitAudit(c, "INFO", "Reaction added")
+	} else {
+		h.emitAudit(c, "INFO", "Reaction removed")
+	}
 	c.Status(http.StatusNoContent)
 }
 
+// MarkGroupRead handles POST /groups/:group_id/read, advancing the caller's read marker.
+// Without a message_id the group is marked read up to its latest message.
+func (h *GroupHandler) MarkGroupRead(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	messageID, ok := bindReadMarker(c)
+	if !ok {
+		return
+	}
+
+	lastRead, err := h.markRead(c.Request.Context(), c.GetInt("userID"), groupID, messageID)
+	if err != nil {
+		respondError(c, err, h.emitAudit)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"last_read_message_id": lastRead})
+}
+
+// markRead advances userID's read marker (0 means the latest message) and broadcasts it when it moved.
+func (h *GroupHandler) markRead(ctx context.Context, userID, groupID, messageID int) (int, error) {
+	member, err := h.groupRepo.IsMember(ctx, groupID, userID)
+	if err != nil {
+		return 0, newRequestError(http.StatusInternalServerError, "membership check failed", "")
+	}
+	if !member {
+		return 0, newRequestError(http.StatusForbidden, "not a member", "")
+	}
+
+	if messageID > 0 {
+		msg, err := h.messageRepo.GetGroupMessage(ctx, messageID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrMessageNotFound) {
+				return 0, newRequestError(http.StatusNotFound, "message not found", "")
+			}
+			return 0, newRequestError(http.StatusInternalServerError, "message not found", "")
+		}
+		if msg.GroupID != groupID {
+			return 0, newRequestError(http.StatusBadRequest, "message does not belong to group", "")
+		}
+	}
+
+	lastRead, advanced, err := h.groupRepo.MarkRead(ctx, groupID, userID, messageID)
+	if err != nil {
+		return 0, newRequestError(http.StatusInternalServerError, "could not mark group read", "")
+	}
+
+	if advanced {
+		h.hub.BroadcastGroupRead(groupID, userID, lastRead)
+	}
+	return lastRead, nil
+}
+
 func (h *GroupHandler) emitAudit(c *gin.Context, level, text string) {
 	if h.audit == nil {
 		return
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-service/internal/models"
+	"chat-service/internal/repositories"
+)
+
+// AddMembers handles POST /groups/:group_id/members (owner and admins).
+func (h *GroupHandler) AddMembers(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	var req struct {
+		UserIDs []int `json:"user_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.emitAudit(c, "ERROR", "invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetInt("userID")
+	if _, ok := h.authorize(c, groupID, userID, actionManageMembers); !ok {
+		return
+	}
+
+	users, err := h.userClient.BulkUsers(c.Request.Context(), req.UserIDs)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to validate members"})
+		return
+	}
+	known := map[int]struct{}{}
+	for _, u := range users {
+		known[int(u.Id)] = struct{}{}
+	}
+	for _, id := range req.UserIDs {
+		if _, ok := known[id]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown user " + strconv.Itoa(id)})
+			return
+		}
+	}
+
+	names := h.displayNames(c.Request.Context(), append([]int{userID}, req.UserIDs...))
+	note := func(added []int) string {
+		addedNames := make([]string, 0, len(added))
+		for _, id := range added {
+			addedNames = append(addedNames, names[id])
+		}
+		return names[userID] + " added " + strings.Join(addedNames, ", ")
+	}
+	added, msg, err := h.groupRepo.AddMembers(requestContext(c), groupID, req.UserIDs, userID, note)
+	if err != nil {
+		h.emitAudit(c, "ERROR", "internal error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not add members"})
+		return
+	}
+
+	if len(added) > 0 {
+		h.hub.SubscribeGroup(groupID, added...)
+		h.hub.BroadcastGroupMessage(groupID, msg)
+		h.emitAudit(c, "INFO", "Group members added")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"added": added})
+}
+
+// RemoveMember handles DELETE /groups/:group_id/members/:user_id.
+// The owner may remove anyone; admins may only remove regular members.
+func (h *GroupHandler) RemoveMember(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+	targetID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	userID := c.GetInt("userID")
+	if targetID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "use leave to exit the group"})
+		return
+	}
+	role, ok := h.authorize(c, groupID, userID, actionManageMembers)
+	if !ok {
+		return
+	}
+	targetRole, err := h.groupRepo.GetMemberRole(c.Request.Context(), groupID, targetID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repositories.ErrNotGroupMember) {
+			status = http.StatusNotFound
+		}
+		if status == http.StatusInternalServerError {
+			h.emitAudit(c, "ERROR", "internal error")
+		}
+		c.JSON(status, gin.H{"error": "could not remove member"})
+		return
+	}
+	if !outranks(role, targetRole) {
+		h.emitAudit(c, "ERROR", "insufficient group role")
+		c.JSON(http.StatusForbidden, gin.H{"error": "cannot remove a member with an equal or higher role"})
+		return
+	}
+
+	names := h.displayNames(c.Request.Context(), []int{userID, targetID})
+	msg, err := h.groupRepo.RemoveMember(requestContext(c), groupID, targetID, targetRole, userID, names[userID]+" removed "+names[targetID])
+	if err != nil {
+		h.memberChangeFailed(c, err, "could not remove member")
+		return
+	}
+
+	h.hub.DisconnectGroupUser(groupID, targetID)
+	h.hub.BroadcastGroupMessage(groupID, msg)
+	h.emitAudit(c, "INFO", "Group member removed")
+	c.Status(http.StatusNoContent)
+}
+
+// LeaveGroup handles POST /groups/:group_id/leave. Ownership passes to the longest-standing admin,
+// or to the longest-standing member when there are no admins.
+func (h *GroupHandler) LeaveGroup(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	userID := c.GetInt("userID")
+	names := h.displayNames(c.Request.Context(), h.leaveNoteUsers(c.Request.Context(), groupID, userID))
+	note := func(newOwnerID int) string {
+		text := names[userID] + " left the group"
+		if newOwnerID != 0 {
+			ownerName, ok := names[newOwnerID]
+			if !ok {
+				ownerName = fmt.Sprintf("user %d", newOwnerID)
+			}
+			text += "; " + ownerName + " is now the owner"
+		}
+		return text
+	}
+	_, msg, err := h.groupRepo.LeaveGroup(requestContext(c), groupID, userID, note)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, repositories.ErrGroupNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, repositories.ErrNotGroupMember):
+			status = http.StatusForbidden
+		}
+		if status == http.StatusInternalServerError {
+			h.emitAudit(c, "ERROR", "internal error")
+		}
+		c.JSON(status, gin.H{"error": "could not leave group"})
+		return
+	}
+
+	h.hub.DisconnectGroupUser(groupID, userID)
+	h.hub.BroadcastGroupMessage(groupID, msg)
+	h.emitAudit(c, "INFO", "Left group")
+	c.Status(http.StatusNoContent)
+}
+
+// PromoteAdmin handles PUT /groups/:group_id/admins/:user_id (owner only).
+func (h *GroupHandler) PromoteAdmin(c *gin.Context) {
+	h.setAdmin(c, models.GroupRoleAdmin, "promoted", "to admin")
+}
+
+// DemoteAdmin handles DELETE /groups/:group_id/admins/:user_id (owner only).
+func (h *GroupHandler) DemoteAdmin(c *gin.Context) {
+	h.setAdmin(c, models.GroupRoleMember, "removed", "as admin")
+}
+
+// setAdmin changes a member's role and records the change in the timeline.
+func (h *GroupHandler) setAdmin(c *gin.Context, role, verb, suffix string) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+	targetID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	userID := c.GetInt("userID")
+	if targetID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot change your own role"})
+		return
+	}
+	if _, ok := h.authorize(c, groupID, userID, actionManageRoles); !ok {
+		return
+	}
+
+	targetRole, err := h.groupRepo.GetMemberRole(c.Request.Context(), groupID, targetID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotGroupMember) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user is not a member"})
+			return
+		}
+		h.emitAudit(c, "ERROR", "internal error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "membership check failed"})
+		return
+	}
+	if targetRole == models.GroupRoleOwner {
+		h.emitAudit(c, "ERROR", "not allowed")
+		c.JSON(http.StatusForbidden, gin.H{"error": "cannot change the owner's role"})
+		return
+	}
+
+	names := h.displayNames(c.Request.Context(), []int{userID, targetID})
+	msg, err := h.groupRepo.SetMemberRole(requestContext(c), groupID, targetID, targetRole, role, userID, names[userID]+" "+verb+" "+names[targetID]+" "+suffix)
+	if err != nil {
+		h.memberChangeFailed(c, err, "could not update role")
+		return
+	}
+
+	h.hub.BroadcastGroupMessage(groupID, msg)
+	h.emitAudit(c, "INFO", "Group role updated")
+	c.JSON(http.StatusOK, gin.H{"user_id": targetID, "role": role})
+}
+
+// memberChangeFailed writes the response for a failed removal or role change. A target whose
+// role changed after the permission check yields 409, so the client can re-check and retry.
+func (h *GroupHandler) memberChangeFailed(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, repositories.ErrNotGroupMember):
+		status = http.StatusNotFound
+	case errors.Is(err, repositories.ErrMemberRoleChanged):
+		h.emitAudit(c, "ERROR", "member role changed")
+		c.JSON(http.StatusConflict, gin.H{"error": "member role changed, try again"})
+		return
+	}
+	if status == http.StatusInternalServerError {
+		h.emitAudit(c, "ERROR", "internal error")
+	}
+	c.JSON(status, gin.H{"error": message})
+}
+
+// displayNames resolves usernames for system messages, falling back to ids when user-service is unavailable.
+func (h *GroupHandler) displayNames(ctx context.Context, ids []int) map[int]string {
+	names := make(map[int]string, len(ids))
+	for _, id := range ids {
+		names[id] = fmt.Sprintf("user %d", id)
+	}
+	users, err := h.userClient.BulkUsers(ctx, ids)
+	if err != nil {
+		log.Printf("system message usernames unavailable: %v", err)
+		return names
+	}
+	for _, u := range users {
+		if u.Username != "" {
+			names[int(u.Id)] = u.Username
+		}
+	}
+	return names
+}
+
+// leaveNoteUsers returns the users the system message for userID leaving may name: the leaver,
+// plus every other member when the owner leaves, since any of them may inherit ownership.
+// Names are resolved before the leave transaction so no user-service call runs while it holds locks.
+func (h *GroupHandler) leaveNoteUsers(ctx context.Context, groupID, userID int) []int {
+	members, err := h.groupRepo.ListMembers(ctx, groupID)
+	if err != nil {
+		log.Printf("system message members unavailable group_id=%d: %v", groupID, err)
+		return []int{userID}
+	}
+	ids := []int{userID}
+	owner := false
+	for _, m := range members {
+		if m.UserID == userID {
+			owner = m.Role == models.GroupRoleOwner
+		} else {
+			ids = append(ids, m.UserID)
+		}
+	}
+	if !owner {
+		return []int{userID}
+	}
+	return ids
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-service/internal/models"
+	"chat-service/internal/repositories"
+)
+
+// groupAction is a privileged operation inside a group.
+type groupAction int
+
+const (
+	// actionManageMembers covers adding and removing members.
+	actionManageMembers groupAction = iota
+	// actionModerateMessages covers deleting other members' messages for everyone.
+	actionModerateMessages
+	// actionEditSettings covers changing group name, description and avatar.
+	actionEditSettings
+	// actionManageRoles covers promoting and demoting admins.
+	actionManageRoles
+)
+
+// roleAllows reports whether a member with role may perform action.
+func roleAllows(role string, action groupAction) bool {
+	switch role {
+	case models.GroupRoleOwner:
+		return true
+	case models.GroupRoleAdmin:
+		return action != actionManageRoles
+	default:
+		return false
+	}
+}
+
+// outranks reports whether actorRole may act on a member holding targetRole (e.g. remove them).
+func outranks(actorRole, targetRole string) bool {
+	rank := func(role string) int {
+		switch role {
+		case models.GroupRoleOwner:
+			return 2
+		case models.GroupRoleAdmin:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return rank(actorRole) > rank(targetRole)
+}
+
+// memberRole loads the caller's role, writing the error response and returning false when
+// the caller is not a member or the lookup fails.
+func (h *GroupHandler) memberRole(c *gin.Context, groupID, userID int) (string, bool) {
+	role, err := h.groupRepo.GetMemberRole(c.Request.Context(), groupID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotGroupMember) {
+			h.emitAudit(c, "ERROR", "not allowed")
+			c.JSON(http.StatusForbidden, gin.H{"error": "not a member"})
+			return "", false
+		}
+		h.emitAudit(c, "ERROR", "internal error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "membership check failed"})
+		return "", false
+	}
+	return role, true
+}
+
+// authorize ensures the caller's role permits action, writing the error response otherwise.
+func (h *GroupHandler) authorize(c *gin.Context, groupID, userID int, action groupAction) (string, bool) {
+	role, ok := h.memberRole(c, groupID, userID)
+	if !ok {
+		return "", false
+	}
+	if !roleAllows(role, action) {
+		h.emitAudit(c, "ERROR", "insufficient group role")
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient group role"})
+		return "", false
+	}
+	return role, true
+}
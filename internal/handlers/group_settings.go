@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-service/internal/models"
+	"chat-service/internal/repositories"
+)
+
+// GetGroup handles GET /groups/:group_id and returns the group's settings and members.
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	userID := c.GetInt("userID")
+	role, ok := h.memberRole(c, groupID, userID)
+	if !ok {
+		return
+	}
+
+	group, err := h.groupRepo.GetGroup(c.Request.Context(), groupID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repositories.ErrGroupNotFound) {
+			status = http.StatusNotFound
+		}
+		if status == http.StatusInternalServerError {
+			h.emitAudit(c, "ERROR", "internal error")
+		}
+		c.JSON(status, gin.H{"error": "group not found"})
+		return
+	}
+
+	members, err := h.groupRepo.ListMembers(c.Request.Context(), groupID)
+	if err != nil {
+		h.emitAudit(c, "ERROR", "internal error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load members"})
+		return
+	}
+
+	ids := make([]int, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	users, err := h.userClient.BulkUsers(c.Request.Context(), ids)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to load user info"})
+		return
+	}
+	usernames := make(map[int]string, len(users))
+	for _, u := range users {
+		usernames[int(u.Id)] = u.Username
+	}
+	for i := range members {
+		members[i].Username = usernames[members[i].UserID]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group":   group,
+		"my_role": role,
+		"members": members,
+	})
+}
+
+// UpdateGroup handles PATCH /groups/:group_id (owner and admins).
+// Only the fields present in the body are changed; an empty description or avatar_url clears it.
+func (h *GroupHandler) UpdateGroup(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	var req struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		AvatarURL   *string `json:"avatar_url"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.emitAudit(c, "ERROR", "invalid request payload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == nil && req.Description == nil && req.AvatarURL == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to update"})
+		return
+	}
+
+	update := repositories.GroupUpdate{}
+	if req.Name != nil {
+		name, msg := normalizeGroupName(*req.Name)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		update.Name = &name
+	}
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if utf8.RuneCountInString(description) > models.GroupDescriptionMaxRunes {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "description must not exceed " + strconv.Itoa(models.GroupDescriptionMaxRunes) + " characters"})
+			return
+		}
+		update.Description = &description
+	}
+	if req.AvatarURL != nil {
+		avatarURL := strings.TrimSpace(*req.AvatarURL)
+		if !validAvatarURL(avatarURL) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "avatar_url must be an absolute http(s) URL"})
+			return
+		}
+		update.AvatarURL = &avatarURL
+	}
+
+	userID := c.GetInt("userID")
+	if _, ok := h.authorize(c, groupID, userID, actionEditSettings); !ok {
+		return
+	}
+
+	before, err := h.groupRepo.GetGroup(c.Request.Context(), groupID)
+	if err != nil {
+		h.groupUpdateFailed(c, err)
+		return
+	}
+	note := ""
+	if update.Name != nil && *update.Name != before.Name {
+		names := h.displayNames(c.Request.Context(), []int{userID})
+		note = names[userID] + " renamed the group to \"" + *update.Name + "\""
+	}
+	group, msg, err := h.groupRepo.UpdateGroup(requestContext(c), groupID, update, userID, note)
+	if err != nil {
+		h.groupUpdateFailed(c, err)
+		return
+	}
+
+	if msg.ID != 0 {
+		h.hub.BroadcastGroupMessage(groupID, msg)
+	}
+	h.hub.BroadcastGroupUpdate(group)
+	h.emitAudit(c, "INFO", "Group updated")
+	c.JSON(http.StatusOK, group)
+}
+
+func (h *GroupHandler) groupUpdateFailed(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, repositories.ErrGroupNotFound) {
+		status = http.StatusNotFound
+	}
+	if status == http.StatusInternalServerError {
+		h.emitAudit(c, "ERROR", "internal error")
+	}
+	c.JSON(status, gin.H{"error": "could not update group"})
+}
+
+// normalizeGroupName trims name and returns it with an empty message, or a validation message.
+func normalizeGroupName(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "name must not be empty"
+	}
+	if utf8.RuneCountInString(name) > models.GroupNameMaxRunes {
+		return "", "name must not exceed " + strconv.Itoa(models.GroupNameMaxRunes) + " characters"
+	}
+	return name, ""
+}
+
+// validAvatarURL accepts an empty string (no avatar) or an absolute http(s) URL.
+func validAvatarURL(raw string) bool {
+	if raw == "" {
+		return true
+	}
+	if len(raw) > models.GroupAvatarURLMaxLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
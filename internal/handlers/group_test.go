@@ -5,13 +5,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"chat-service/internal/mocks"
 	"chat-service/internal/models"
+	"chat-service/internal/repositories"
 	"chat-service/internal/ws"
 	userpb "chat-service/pb/user"
 )
@@ -26,6 +29,15 @@ func setupGroupRouter(handler *GroupHandler) *gin.Engine {
 	r.POST("/groups", handler.CreateGroup)
 	r.GET("/groups/:group_id/messages", handler.GetGroupMessages)
 	r.POST("/groups/:group_id/messages", handler.PostGroupMessage)
+	r.PATCH("/groups/:group_id/messages/:message_id", handler.EditGroupMessage)
+	r.PUT("/groups/:group_id/messages/:message_id/reactions/:emoji", handler.AddGroupReaction)
+	r.POST("/groups/:group_id/members", handler.AddMembers)
+	r.DELETE("/groups/:group_id/members/:user_id", handler.RemoveMember)
+	r.POST("/groups/:group_id/leave", handler.LeaveGroup)
+	r.DELETE("/groups/:group_id/messages/:message_id/all", handler.DeleteGroupMessageForAll)
+	r.PUT("/groups/:group_id/admins/:user_id", handler.PromoteAdmin)
+	r.GET("/groups/:group_id", handler.GetGroup)
+	r.PATCH("/groups/:group_id", handler.UpdateGroup)
 	return r
 }
 
@@ -33,7 +45,7 @@ func TestCreateGroupSuccess(t *testing.T) {
 	groupRepo := new(mocks.GroupRepositoryMock)
 	messageRepo := new(mocks.GroupMessageRepositoryMock)
 	userClient := new(mocks.UserClientMock)
-	handler := NewGroupHandler(groupRepo, messageRepo, userClient, nil, nil)
+	handler := NewGroupHandler(groupRepo, messageRepo, userClient, ws.NewHub(), nil)
 	router := setupGroupRouter(handler)
 
 	body := bytes.NewBufferString(`{"name":"test","member_ids":[2]}`)
@@ -69,7 +81,7 @@ func TestGetGroupMessagesSuccess(t *testing.T) {
 	router := setupGroupRouter(handler)
 
 	groupRepo.On("IsMember", mock.Anything, 9, 1).Return(true, nil).Once()
-	messageRepo.On("ListGroupMessages", mock.Anything, 9).Return([]models.GroupMessage{{ID: 1, GroupID: 9, SenderID: 1}}, nil).Once()
+	messageRepo.On("ListGroupMessages", mock.Anything, 9, 1, repositories.PageRequest{}).Return([]models.GroupMessage{{ID: 1, GroupID: 9, SenderID: 1}}, 0, nil).Once()
 	userClient.On("BulkUsers", mock.Anything, []int{1}).Return([]*userpb.GetUserResponse{{Id: 1, Username: "me"}}, nil).Once()
 
 	req := httptest.NewRequest(http.MethodGet, "/groups/9/messages", nil)
@@ -101,7 +113,7 @@ func TestPostGroupMessageSuccess(t *testing.T) {
 	router := setupGroupRouter(handler)
 
 	groupRepo.On("IsMember", mock.Anything, 9, 1).Return(true, nil).Once()
-	messageRepo.On("CreateGroupMessage", mock.Anything, 9, 1, "hey").Return(models.GroupMessage{ID: 3, GroupID: 9, SenderID: 1, Content: "hey"}, nil).Once()
+	messageRepo.On("CreateGroupMessage", mock.Anything, 9, 1, "hey", (*int)(nil), ([]int)(nil)).Return(models.GroupMessage{ID: 3, GroupID: 9, SenderID: 1, Content: "hey"}, nil).Once()
 
 	req := httptest.NewRequest(http.MethodPost, "/groups/9/messages", bytes.NewBufferString(`{"content":"hey"}`))
 	rec := httptest.NewRecorder()
@@ -122,3 +134,357 @@ func TestPostGroupMessageInvalidID(t *testing.T) {
 
 	require.Equal(t, http.StatusBadRequest, rec.Code)
 }
+
+func TestEditGroupMessageSuccess(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	messageRepo := new(mocks.GroupMessageRepositoryMock)
+	handler := NewGroupHandler(groupRepo, messageRepo, nil, ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("IsMember", mock.Anything, 9, 1).Return(true, nil).Once()
+	messageRepo.On("GetGroupMessage", mock.Anything, 3).Return(models.GroupMessage{ID: 3, GroupID: 9, SenderID: 1, CreatedAt: time.Now()}, nil).Once()
+	messageRepo.On("EditGroupMessage", mock.Anything, 3, 1, "fixed").Return(models.GroupMessage{ID: 3, GroupID: 9, SenderID: 1, Content: "fixed"}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPatch, "/groups/9/messages/3", bytes.NewBufferString(`{"content":"fixed"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	groupRepo.AssertExpectations(t)
+	messageRepo.AssertExpectations(t)
+}
+
+func TestAddGroupReactionNotMember(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	messageRepo := new(mocks.GroupMessageRepositoryMock)
+	handler := NewGroupHandler(groupRepo, messageRepo, nil, ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("IsMember", mock.Anything, 9, 1).Return(false, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPut, "/groups/9/messages/3/reactions/%F0%9F%91%8D", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	messageRepo.AssertNotCalled(t, "AddReaction", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAddMembersSuccess(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	messageRepo := new(mocks.GroupMessageRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	handler := NewGroupHandler(groupRepo, messageRepo, userClient, ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleAdmin, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{2, 3}).Return([]*userpb.GetUserResponse{{Id: 2, Username: "bob"}, {Id: 3, Username: "eve"}}, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{1, 2, 3}).Return([]*userpb.GetUserResponse{{Id: 1, Username: "alice"}, {Id: 2, Username: "bob"}, {Id: 3, Username: "eve"}}, nil).Once()
+	groupRepo.On("AddMembers", mock.Anything, 9, []int{2, 3}, 1, mock.Anything).Run(func(args mock.Arguments) {
+		note := args.Get(4).(func([]int) string)
+		assert.Equal(t, "alice added eve", note([]int{3}))
+	}).Return([]int{3}, models.GroupMessage{ID: 20, GroupID: 9, Kind: models.GroupMessageKindSystem}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/groups/9/members", bytes.NewBufferString(`{"user_ids":[2,3]}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"added":[3]}`, rec.Body.String())
+	groupRepo.AssertExpectations(t)
+	messageRepo.AssertExpectations(t)
+	userClient.AssertExpectations(t)
+}
+
+func TestAddMembersRegularMember(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	handler := NewGroupHandler(groupRepo, new(mocks.GroupMessageRepositoryMock), new(mocks.UserClientMock), ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleMember, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/groups/9/members", bytes.NewBufferString(`{"user_ids":[3]}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	groupRepo.AssertNotCalled(t, "AddMembers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRemoveMemberSelf(t *testing.T) {
+	handler := NewGroupHandler(new(mocks.GroupRepositoryMock), new(mocks.GroupMessageRepositoryMock), new(mocks.UserClientMock), ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	req := httptest.NewRequest(http.MethodDelete, "/groups/9/members/1", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRemoveMemberAdminCannotRemoveAdmin(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	handler := NewGroupHandler(groupRepo, new(mocks.GroupMessageRepositoryMock), new(mocks.UserClientMock), ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleAdmin, nil).Once()
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 5).Return(models.GroupRoleAdmin, nil).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/groups/9/members/5", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	groupRepo.AssertNotCalled(t, "RemoveMember", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRemoveMemberConflictWhenTargetRoleChanged(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	handler := NewGroupHandler(groupRepo, new(mocks.GroupMessageRepositoryMock), userClient, ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleAdmin, nil).Once()
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 5).Return(models.GroupRoleMember, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{1, 5}).Return([]*userpb.GetUserResponse{{Id: 1, Username: "alice"}, {Id: 5, Username: "bob"}}, nil).Once()
+	// the target was promoted between the permission check and the removal
+	groupRepo.On("RemoveMember", mock.Anything, 9, 5, models.GroupRoleMember, 1, "alice removed bob").
+		Return(nil, repositories.ErrMemberRoleChanged).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/groups/9/members/5", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusConflict, rec.Code)
+	groupRepo.AssertExpectations(t)
+}
+
+func TestDeleteGroupMessageForAllByAdmin(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	messageRepo := new(mocks.GroupMessageRepositoryMock)
+	handler := NewGroupHandler(groupRepo, messageRepo, new(mocks.UserClientMock), ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleAdmin, nil).Once()
+	messageRepo.On("GetGroupMessage", mock.Anything, 3).Return(models.GroupMessage{ID: 3, GroupID: 9, SenderID: 7, Kind: models.GroupMessageKindUser}, nil).Once()
+	messageRepo.On("DeleteForAll", mock.Anything, 3, 1).Return(true, nil).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/groups/9/messages/3/all", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	messageRepo.AssertExpectations(t)
+}
+
+func TestDeleteGroupMessageForAllAlreadyDeleted(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	messageRepo := new(mocks.GroupMessageRepositoryMock)
+	handler := NewGroupHandler(groupRepo, messageRepo, new(mocks.UserClientMock), ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleMember, nil).Once()
+	messageRepo.On("GetGroupMessage", mock.Anything, 3).Return(models.GroupMessage{ID: 3, GroupID: 9, SenderID: 1, Kind: models.GroupMessageKindUser, DeletedForAll: true}, nil).Once()
+	messageRepo.On("DeleteForAll", mock.Anything, 3, 1).Return(false, nil).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/groups/9/messages/3/all", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	messageRepo.AssertExpectations(t)
+}
+
+func TestDeleteGroupMessageForAllByMemberForbidden(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	messageRepo := new(mocks.GroupMessageRepositoryMock)
+	handler := NewGroupHandler(groupRepo, messageRepo, new(mocks.UserClientMock), ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleMember, nil).Once()
+	messageRepo.On("GetGroupMessage", mock.Anything, 3).Return(models.GroupMessage{ID: 3, GroupID: 9, SenderID: 7, Kind: models.GroupMessageKindUser}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/groups/9/messages/3/all", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	messageRepo.AssertNotCalled(t, "DeleteForAll", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPromoteAdminRequiresOwner(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	handler := NewGroupHandler(groupRepo, new(mocks.GroupMessageRepositoryMock), new(mocks.UserClientMock), ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleAdmin, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPut, "/groups/9/admins/5", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	groupRepo.AssertNotCalled(t, "SetMemberRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPromoteAdminRejectsOwnerTarget(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	handler := NewGroupHandler(groupRepo, new(mocks.GroupMessageRepositoryMock), new(mocks.UserClientMock), ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleOwner, nil).Once()
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 5).Return(models.GroupRoleOwner, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPut, "/groups/9/admins/5", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.JSONEq(t, `{"error":"cannot change the owner's role"}`, rec.Body.String())
+	groupRepo.AssertNotCalled(t, "SetMemberRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPromoteAdminConflictWhenTargetRoleChanged(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	handler := NewGroupHandler(groupRepo, new(mocks.GroupMessageRepositoryMock), userClient, ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleOwner, nil).Once()
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 5).Return(models.GroupRoleMember, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{1, 5}).Return(([]*userpb.GetUserResponse)(nil), assert.AnError).Once()
+	groupRepo.On("SetMemberRole", mock.Anything, 9, 5, models.GroupRoleMember, models.GroupRoleAdmin, 1, "user 1 promoted user 5 to admin").
+		Return(nil, repositories.ErrMemberRoleChanged).Once()
+
+	req := httptest.NewRequest(http.MethodPut, "/groups/9/admins/5", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusConflict, rec.Code)
+	groupRepo.AssertExpectations(t)
+}
+
+func TestGetGroupIncludesMembers(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	handler := NewGroupHandler(groupRepo, new(mocks.GroupMessageRepositoryMock), userClient, ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	joined := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleMember, nil).Once()
+	groupRepo.On("GetGroup", mock.Anything, 9).Return(models.Group{ID: 9, Name: "team", Description: "hi", OwnerID: 2, CreatedAt: joined}, nil).Once()
+	groupRepo.On("ListMembers", mock.Anything, 9).Return([]models.GroupMember{
+		{UserID: 2, Role: models.GroupRoleOwner, JoinedAt: joined},
+		{UserID: 1, Role: models.GroupRoleMember, JoinedAt: joined},
+	}, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{2, 1}).Return([]*userpb.GetUserResponse{{Id: 1, Username: "alice"}, {Id: 2, Username: "bob"}}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/groups/9", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{
+		"group": {"id": 9, "name": "team", "description": "hi", "avatar_url": "", "owner_id": 2, "created_at": "2024-01-02T03:04:05Z"},
+		"my_role": "member",
+		"members": [
+			{"user_id": 2, "username": "bob", "role": "owner", "joined_at": "2024-01-02T03:04:05Z"},
+			{"user_id": 1, "username": "alice", "role": "member", "joined_at": "2024-01-02T03:04:05Z"}
+		]
+	}`, rec.Body.String())
+}
+
+func TestUpdateGroupRename(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	messageRepo := new(mocks.GroupMessageRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	handler := NewGroupHandler(groupRepo, messageRepo, userClient, ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	name := "new name"
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleAdmin, nil).Once()
+	groupRepo.On("GetGroup", mock.Anything, 9).Return(models.Group{ID: 9, Name: "old"}, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{1}).Return([]*userpb.GetUserResponse{{Id: 1, Username: "alice"}}, nil).Once()
+	groupRepo.On("UpdateGroup", mock.Anything, 9, repositories.GroupUpdate{Name: &name}, 1, `alice renamed the group to "new name"`).
+		Return(models.Group{ID: 9, Name: name}, models.GroupMessage{ID: 30, GroupID: 9}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPatch, "/groups/9", bytes.NewBufferString(`{"name":"  new name  "}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	groupRepo.AssertExpectations(t)
+	messageRepo.AssertExpectations(t)
+}
+
+func TestUpdateGroupInvalidAvatarURL(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	handler := NewGroupHandler(groupRepo, new(mocks.GroupMessageRepositoryMock), new(mocks.UserClientMock), ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	req := httptest.NewRequest(http.MethodPatch, "/groups/9", bytes.NewBufferString(`{"avatar_url":"javascript:alert(1)"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	groupRepo.AssertNotCalled(t, "UpdateGroup", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateGroupRegularMemberForbidden(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	handler := NewGroupHandler(groupRepo, new(mocks.GroupMessageRepositoryMock), new(mocks.UserClientMock), ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("GetMemberRole", mock.Anything, 9, 1).Return(models.GroupRoleMember, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPatch, "/groups/9", bytes.NewBufferString(`{"description":"x"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	groupRepo.AssertNotCalled(t, "UpdateGroup", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLeaveGroupTransfersOwnership(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	messageRepo := new(mocks.GroupMessageRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	handler := NewGroupHandler(groupRepo, messageRepo, userClient, ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("ListMembers", mock.Anything, 9).Return([]models.GroupMember{
+		{UserID: 1, Role: models.GroupRoleOwner},
+		{UserID: 4, Role: models.GroupRoleAdmin},
+		{UserID: 6, Role: models.GroupRoleMember},
+	}, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{1, 4, 6}).Return(([]*userpb.GetUserResponse)(nil), assert.AnError).Once()
+	groupRepo.On("LeaveGroup", mock.Anything, 9, 1, mock.Anything).Run(func(args mock.Arguments) {
+		note := args.Get(3).(func(int) string)
+		assert.Equal(t, "user 1 left the group; user 4 is now the owner", note(4))
+	}).Return(4, models.GroupMessage{ID: 21, GroupID: 9}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/groups/9/leave", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	groupRepo.AssertExpectations(t)
+	messageRepo.AssertExpectations(t)
+}
+
+func TestLeaveGroupNotMember(t *testing.T) {
+	groupRepo := new(mocks.GroupRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	handler := NewGroupHandler(groupRepo, new(mocks.GroupMessageRepositoryMock), userClient, ws.NewHub(), nil)
+	router := setupGroupRouter(handler)
+
+	groupRepo.On("ListMembers", mock.Anything, 9).Return([]models.GroupMember{{UserID: 2, Role: models.GroupRoleOwner}}, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{1}).Return(([]*userpb.GetUserResponse)(nil), nil).Once()
+	groupRepo.On("LeaveGroup", mock.Anything, 9, 1, mock.Anything).Return(0, nil, repositories.ErrNotGroupMember).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/groups/9/leave", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-service/internal/repositories"
+)
+
+// parsePageRequest reads the before/after/limit query parameters used by message history endpoints.
+func parsePageRequest(c *gin.Context) (repositories.PageRequest, bool) {
+	var page repositories.PageRequest
+
+	parse := func(name string, dst *int) bool {
+		raw := c.Query(name)
+		if raw == "" {
+			return true
+		}
+		val, err := strconv.Atoi(raw)
+		if err != nil || val <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+			return false
+		}
+		*dst = val
+		return true
+	}
+
+	if !parse("before", &page.Before) || !parse("after", &page.After) || !parse("limit", &page.Limit) {
+		return repositories.PageRequest{}, false
+	}
+	if page.Before > 0 && page.After > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "before and after are mutually exclusive"})
+		return repositories.PageRequest{}, false
+	}
+	if page.Limit > repositories.MaxPageLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must not exceed " + strconv.Itoa(repositories.MaxPageLimit)})
+		return repositories.PageRequest{}, false
+	}
+	return page, true
+}
+
+// nextCursor converts a repository cursor into its JSON form (null when there is no further page).
+func nextCursor(cursor int) *int {
+	if cursor == 0 {
+		return nil
+	}
+	return &cursor
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-service/internal/presence"
+	"chat-service/internal/repositories"
+)
+
+// MaxPresenceLookup caps the number of users in one presence request.
+const MaxPresenceLookup = 100
+
+// MaxPresenceFriendChecks caps the friendship lookups one presence request makes for ids that
+// share no chat or group with the caller; further such ids are left out.
+const MaxPresenceFriendChecks = 10
+
+// PresenceHandler serves online status and last-seen lookups.
+type PresenceHandler struct {
+	tracker      *presence.Tracker
+	presenceRepo repositories.PresenceRepository
+	userClient   userClient
+}
+
+// NewPresenceHandler constructs a PresenceHandler.
+func NewPresenceHandler(tracker *presence.Tracker, presenceRepo repositories.PresenceRepository, userClient userClient) *PresenceHandler {
+	return &PresenceHandler{tracker: tracker, presenceRepo: presenceRepo, userClient: userClient}
+}
+
+// GetPresence handles GET /presence?user_ids=1,2,3. Only the caller, friends and users sharing
+// a chat or group with the caller are visible; other ids are left out of the response. Friends
+// without a shared conversation are only checked for the first MaxPresenceFriendChecks of them.
+func (h *PresenceHandler) GetPresence(c *gin.Context) {
+	ids, ok := parseUserIDs(c, c.Query("user_ids"))
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt("userID")
+	contacts, err := h.presenceRepo.ContactIDs(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load contacts"})
+		return
+	}
+	visible := map[int]struct{}{userID: {}}
+	for _, id := range contacts {
+		visible[id] = struct{}{}
+	}
+
+	allowed := make([]int, 0, len(ids))
+	checks := 0
+	for _, id := range ids {
+		if _, ok := visible[id]; !ok {
+			if checks == MaxPresenceFriendChecks {
+				continue
+			}
+			checks++
+			friends, err := h.userClient.AreFriends(c.Request.Context(), userID, id)
+			if err != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": "failed to validate friendship"})
+				return
+			}
+			if !friends {
+				continue
+			}
+		}
+		allowed = append(allowed, id)
+	}
+
+	result, err := h.tracker.Lookup(c.Request.Context(), allowed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load presence"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"presence": result})
+}
+
+// parseUserIDs reads a comma-separated list of distinct positive user ids.
+func parseUserIDs(c *gin.Context, raw string) ([]int, bool) {
+	if raw == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_ids is required"})
+		return nil, false
+	}
+	parts := strings.Split(raw, ",")
+	if len(parts) > MaxPresenceLookup {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at most " + strconv.Itoa(MaxPresenceLookup) + " user_ids are allowed"})
+		return nil, false
+	}
+	seen := make(map[int]struct{}, len(parts))
+	ids := make([]int, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id " + strconv.Quote(part)})
+			return nil, false
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"chat-service/internal/mocks"
+	"chat-service/internal/presence"
+	"chat-service/internal/ws"
+)
+
+func setupPresenceRouter(handler *PresenceHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	r.GET("/presence", handler.GetPresence)
+	return r
+}
+
+func TestGetPresenceHidesStrangers(t *testing.T) {
+	presenceRepo := new(mocks.PresenceRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	tracker := presence.NewTracker(presenceRepo, ws.NewHub())
+	handler := NewPresenceHandler(tracker, presenceRepo, userClient)
+	router := setupPresenceRouter(handler)
+
+	seen := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	presenceRepo.On("ContactIDs", mock.Anything, 1).Return([]int{2}, nil).Once()
+	userClient.On("AreFriends", mock.Anything, 1, 3).Return(true, nil).Once()
+	userClient.On("AreFriends", mock.Anything, 1, 4).Return(false, nil).Once()
+	presenceRepo.On("LastSeen", mock.Anything, []int{2, 3}).Return(map[int]time.Time{2: seen}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/presence?user_ids=2,3,4,2", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"presence":[
+		{"user_id":2,"online":false,"last_seen_at":"2024-05-01T12:00:00Z"},
+		{"user_id":3,"online":false}
+	]}`, rec.Body.String())
+	presenceRepo.AssertExpectations(t)
+	userClient.AssertExpectations(t)
+}
+
+func TestGetPresenceCapsFriendChecks(t *testing.T) {
+	presenceRepo := new(mocks.PresenceRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	tracker := presence.NewTracker(presenceRepo, ws.NewHub())
+	handler := NewPresenceHandler(tracker, presenceRepo, userClient)
+	router := setupPresenceRouter(handler)
+
+	presenceRepo.On("ContactIDs", mock.Anything, 1).Return([]int{}, nil).Once()
+	userClient.On("AreFriends", mock.Anything, 1, mock.Anything).Return(false, nil)
+	presenceRepo.On("LastSeen", mock.Anything, []int{}).Return(map[int]time.Time{}, nil).Maybe()
+
+	query := "2"
+	for id := 3; id < 2+MaxPresenceFriendChecks+5; id++ {
+		query += "," + strconv.Itoa(id)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/presence?user_ids="+query, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	userClient.AssertNumberOfCalls(t, "AreFriends", MaxPresenceFriendChecks)
+}
+
+func TestGetPresenceInvalidIDs(t *testing.T) {
+	handler := NewPresenceHandler(nil, new(mocks.PresenceRepositoryMock), new(mocks.UserClientMock))
+	router := setupPresenceRouter(handler)
+
+	for _, query := range []string{"", "?user_ids=", "?user_ids=1,abc", "?user_ids=0"} {
+		req := httptest.NewRequest(http.MethodGet, "/presence"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxEmojiRunes allows multi-codepoint emoji such as skin-tone and ZWJ sequences.
+const maxEmojiRunes = 16
+
+// parseEmoji reads and validates the :emoji path parameter.
+func parseEmoji(c *gin.Context) (string, bool) {
+	emoji := c.Param("emoji")
+	if !validEmoji(emoji) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid emoji"})
+		return "", false
+	}
+	return emoji, true
+}
+
+func validEmoji(emoji string) bool {
+	if emoji == "" || !utf8.ValidString(emoji) || utf8.RuneCountInString(emoji) > maxEmojiRunes {
+		return false
+	}
+	return strings.IndexFunc(emoji, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r) || r == '/'
+	}) == -1
+}
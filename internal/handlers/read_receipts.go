@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindReadMarker reads the optional {"message_id": N} body of the read endpoints.
+// An empty body yields 0, meaning "up to the latest message".
+func bindReadMarker(c *gin.Context) (int, bool) {
+	var req struct {
+		MessageID int `json:"message_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	if req.MessageID < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message id"})
+		return 0, false
+	}
+	return req.MessageID, true
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestError is a failed request together with the HTTP status and message reported to the
+// caller. Operations shared by REST and websocket clients return it so both transports answer
+// identically; audit is the text emitted to the audit log, empty when the failure is not audited.
+type requestError struct {
+	status  int
+	message string
+	audit   string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// StatusCode reports the HTTP status of the failure (see ws.StatusError).
+func (e *requestError) StatusCode() int {
+	return e.status
+}
+
+func newRequestError(status int, message, audit string) *requestError {
+	return &requestError{status: status, message: message, audit: audit}
+}
+
+// respondError writes err as a JSON error response, emitting its audit text through emit.
+func respondError(c *gin.Context, err error, emit func(c *gin.Context, level, text string)) {
+	var reqErr *requestError
+	if !errors.As(err, &reqErr) {
+		reqErr = newRequestError(http.StatusInternalServerError, "internal error", "internal error")
+	}
+	if reqErr.audit != "" {
+		emit(c, "ERROR", reqErr.audit)
+	}
+	c.JSON(reqErr.status, gin.H{"error": reqErr.message})
+}
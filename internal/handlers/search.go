@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-service/internal/models"
+	"chat-service/internal/repositories"
+)
+
+// MaxSearchQueryRunes caps the length of a search query.
+const MaxSearchQueryRunes = 200
+
+// MaxSearchOffset caps how deep search results can be paged.
+const MaxSearchOffset = 1000
+
+// SearchHandler serves full-text search across the caller's chats and groups.
+type SearchHandler struct {
+	searchRepo repositories.SearchRepository
+	userClient userClient
+}
+
+// NewSearchHandler constructs a SearchHandler.
+func NewSearchHandler(searchRepo repositories.SearchRepository, userClient userClient) *SearchHandler {
+	return &SearchHandler{searchRepo: searchRepo, userClient: userClient}
+}
+
+// SearchMessages handles GET /search/messages?q=. Only messages the caller can currently see in
+// their chats and groups are searched.
+func (h *SearchHandler) SearchMessages(c *gin.Context) {
+	query, ok := parseSearchQuery(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt("userID")
+	results, next, err := h.searchRepo.SearchMessages(c.Request.Context(), userID, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search messages"})
+		return
+	}
+
+	if len(results) > 0 {
+		senderIDs := make([]int, 0, len(results))
+		seen := map[int]struct{}{}
+		for _, r := range results {
+			if _, ok := seen[r.SenderID]; !ok {
+				seen[r.SenderID] = struct{}{}
+				senderIDs = append(senderIDs, r.SenderID)
+			}
+		}
+		users, err := h.userClient.BulkUsers(c.Request.Context(), senderIDs)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to load senders"})
+			return
+		}
+		names := make(map[int]string, len(users))
+		for _, u := range users {
+			names[int(u.Id)] = u.Username
+		}
+		for i := range results {
+			results[i].SenderUsername = names[results[i].SenderID]
+		}
+	} else {
+		results = []models.SearchResult{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results, "next_offset": nextCursor(next)})
+}
+
+// parseSearchQuery validates the search text, filters and paging parameters.
+func parseSearchQuery(c *gin.Context) (repositories.SearchQuery, bool) {
+	fail := func(msg string) (repositories.SearchQuery, bool) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return repositories.SearchQuery{}, false
+	}
+
+	query := repositories.SearchQuery{Text: strings.TrimSpace(c.Query("q"))}
+	if query.Text == "" {
+		return fail("q is required")
+	}
+	if utf8.RuneCountInString(query.Text) > MaxSearchQueryRunes {
+		return fail("q must not exceed " + strconv.Itoa(MaxSearchQueryRunes) + " characters")
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{{"chat_id", &query.ChatID}, {"group_id", &query.GroupID}, {"sender_id", &query.SenderID}, {"limit", &query.Limit}}
+	for _, param := range ints {
+		name, dst := param.name, param.dst
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		val, err := strconv.Atoi(raw)
+		if err != nil || val <= 0 {
+			return fail("invalid " + name)
+		}
+		*dst = val
+	}
+	if query.ChatID > 0 && query.GroupID > 0 {
+		return fail("chat_id and group_id are mutually exclusive")
+	}
+	if query.Limit > repositories.MaxSearchLimit {
+		return fail("limit must not exceed " + strconv.Itoa(repositories.MaxSearchLimit))
+	}
+
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return fail("invalid offset")
+		}
+		if offset > MaxSearchOffset {
+			return fail("offset must not exceed " + strconv.Itoa(MaxSearchOffset))
+		}
+		query.Offset = offset
+	}
+
+	times := []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &query.From}, {"to", &query.To}}
+	for _, param := range times {
+		name, dst := param.name, param.dst
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return fail("invalid " + name + ", expected RFC 3339")
+		}
+		*dst = &at
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return fail("from must be before to")
+	}
+	return query, true
+}
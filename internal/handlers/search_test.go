@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"chat-service/internal/mocks"
+	"chat-service/internal/models"
+	"chat-service/internal/repositories"
+	userpb "chat-service/pb/user"
+)
+
+func setupSearchRouter(handler *SearchHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	r.GET("/search/messages", handler.SearchMessages)
+	return r
+}
+
+func TestSearchMessagesSuccess(t *testing.T) {
+	searchRepo := new(mocks.SearchRepositoryMock)
+	userClient := new(mocks.UserClientMock)
+	router := setupSearchRouter(NewSearchHandler(searchRepo, userClient))
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	chatID := 3
+	query := repositories.SearchQuery{Text: "hello world", SenderID: 2, From: &from, Limit: 1}
+	searchRepo.On("SearchMessages", mock.Anything, 1, query).Return([]models.SearchResult{{
+		Scope:     models.SearchScopeChat,
+		MessageID: 10,
+		ChatID:    &chatID,
+		SenderID:  2,
+		CreatedAt: from,
+		Snippet:   "<mark>hello</mark> <mark>world</mark>",
+	}}, 1, nil).Once()
+	userClient.On("BulkUsers", mock.Anything, []int{2}).Return([]*userpb.GetUserResponse{{Id: 2, Username: "bob"}}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/search/messages?q=+hello+world+&sender_id=2&from=2024-05-01T00:00:00Z&limit=1", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"results":[{
+		"scope":"chat","message_id":10,"chat_id":3,"sender_id":2,"sender_username":"bob",
+		"created_at":"2024-05-01T00:00:00Z","snippet":"<mark>hello</mark> <mark>world</mark>"
+	}],"next_offset":1}`, rec.Body.String())
+	searchRepo.AssertExpectations(t)
+	userClient.AssertExpectations(t)
+}
+
+func TestSearchMessagesNoResults(t *testing.T) {
+	searchRepo := new(mocks.SearchRepositoryMock)
+	router := setupSearchRouter(NewSearchHandler(searchRepo, new(mocks.UserClientMock)))
+
+	searchRepo.On("SearchMessages", mock.Anything, 1, repositories.SearchQuery{Text: "nothing", GroupID: 4}).Return(nil, 0, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/search/messages?q=nothing&group_id=4", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"results":[],"next_offset":null}`, rec.Body.String())
+}
+
+func TestSearchMessagesInvalidQuery(t *testing.T) {
+	router := setupSearchRouter(NewSearchHandler(new(mocks.SearchRepositoryMock), new(mocks.UserClientMock)))
+
+	for _, query := range []string{
+		"",
+		"?q=+",
+		"?q=hi&chat_id=1&group_id=2",
+		"?q=hi&sender_id=abc",
+		"?q=hi&limit=51",
+		"?q=hi&offset=-1",
+		"?q=hi&from=yesterday",
+		"?q=hi&from=2024-05-02T00:00:00Z&to=2024-05-01T00:00:00Z",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/search/messages"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
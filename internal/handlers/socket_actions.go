@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"chat-service/internal/models"
+	"chat-service/internal/telemetry"
+	"chat-service/internal/ws"
+)
+
+// SocketActions exposes chat operations to websocket clients (see ws.ChatActions).
+func (h *ChatHandler) SocketActions() ws.ChatActions {
+	return chatSocketActions{h: h}
+}
+
+// SocketActions exposes group operations to websocket clients (see ws.GroupActions).
+func (h *GroupHandler) SocketActions() ws.GroupActions {
+	return groupSocketActions{h: h}
+}
+
+type chatSocketActions struct {
+	h *ChatHandler
+}
+
+func (a chatSocketActions) SendMessage(ctx context.Context, userID, chatID int, content string, replyToID *int, attachmentIDs []int) (models.Message, error) {
+	msg, err := a.h.sendMessage(ctx, userID, chatID, content, replyToID, attachmentIDs)
+	emitSocketAudit(ctx, a.h.audit, userID, err, "Message sent")
+	return msg, err
+}
+
+func (a chatSocketActions) DeleteMessage(ctx context.Context, userID, chatID, messageID int) error {
+	err := a.h.deleteForAll(ctx, userID, chatID, messageID)
+	emitSocketAudit(ctx, a.h.audit, userID, err, "Message deleted for all")
+	return err
+}
+
+func (a chatSocketActions) MarkRead(ctx context.Context, userID, chatID, messageID int) (int, error) {
+	return a.h.markRead(ctx, userID, chatID, messageID)
+}
+
+type groupSocketActions struct {
+	h *GroupHandler
+}
+
+func (a groupSocketActions) SendMessage(ctx context.Context, userID, groupID int, content string, replyToID *int, attachmentIDs []int) (models.GroupMessage, error) {
+	msg, err := a.h.sendMessage(ctx, userID, groupID, content, replyToID, attachmentIDs)
+	emitSocketAudit(ctx, a.h.audit, userID, err, "Group message sent")
+	return msg, err
+}
+
+func (a groupSocketActions) DeleteMessage(ctx context.Context, userID, groupID, messageID int) error {
+	err := a.h.deleteForAll(ctx, userID, groupID, messageID)
+	emitSocketAudit(ctx, a.h.audit, userID, err, "Group message deleted for all")
+	return err
+}
+
+func (a groupSocketActions) MarkRead(ctx context.Context, userID, groupID, messageID int) (int, error) {
+	return a.h.markRead(ctx, userID, groupID, messageID)
+}
+
+// emitSocketAudit mirrors the audit entries of the REST handlers for websocket requests,
+// which carry no request id header.
+func emitSocketAudit(ctx context.Context, audit *telemetry.AuditEmitter, userID int, err error, success string) {
+	if audit == nil {
+		return
+	}
+	uid := int64(userID)
+	if err == nil {
+		audit.Emit(ctx, "INFO", success, "", &uid)
+		return
+	}
+	var reqErr *requestError
+	if !errors.As(err, &reqErr) {
+		audit.Emit(ctx, "ERROR", "internal error", "", &uid)
+		return
+	}
+	if reqErr.audit != "" {
+		audit.Emit(ctx, "ERROR", reqErr.audit, "", &uid)
+	}
+}
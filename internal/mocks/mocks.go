@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 
@@ -56,12 +57,26 @@ func (m *ChatRepositoryMock) UnhideChatForUser(ctx context.Context, chatID int,
 	return args.Error(0)
 }
 
+func (m *ChatRepositoryMock) MarkRead(ctx context.Context, chatID int, userID int, messageID int) (int, bool, error) {
+	args := m.Called(ctx, chatID, userID, messageID)
+	return args.Int(0), args.Bool(1), args.Error(2)
+}
+
+func (m *ChatRepositoryMock) ListChatIDs(ctx context.Context, userID int) ([]int, error) {
+	args := m.Called(ctx, userID)
+	var ids []int
+	if val := args.Get(0); val != nil {
+		ids = val.([]int)
+	}
+	return ids, args.Error(1)
+}
+
 type MessageRepositoryMock struct {
 	mock.Mock
 }
 
-func (m *MessageRepositoryMock) CreateChatMessage(ctx context.Context, chatID int, senderID int, content string) (models.Message, error) {
-	args := m.Called(ctx, chatID, senderID, content)
+func (m *MessageRepositoryMock) CreateChatMessage(ctx context.Context, chatID int, senderID int, content string, replyToID *int, attachmentIDs []int) (models.Message, error) {
+	args := m.Called(ctx, chatID, senderID, content, replyToID, attachmentIDs)
 	var msg models.Message
 	if val := args.Get(0); val != nil {
 		msg = val.(models.Message)
@@ -69,13 +84,13 @@ func (m *MessageRepositoryMock) CreateChatMessage(ctx context.Context, chatID in
 	return msg, args.Error(1)
 }
 
-func (m *MessageRepositoryMock) GetChatMessagesForUser(ctx context.Context, chatID int, userID int) ([]models.Message, error) {
-	args := m.Called(ctx, chatID, userID)
+func (m *MessageRepositoryMock) GetChatMessagesForUser(ctx context.Context, chatID int, userID int, page repositories.PageRequest) ([]models.Message, int, error) {
+	args := m.Called(ctx, chatID, userID, page)
 	var msgs []models.Message
 	if val := args.Get(0); val != nil {
 		msgs = val.([]models.Message)
 	}
-	return msgs, args.Error(1)
+	return msgs, args.Int(1), args.Error(2)
 }
 
 func (m *MessageRepositoryMock) GetMessage(ctx context.Context, messageID int) (models.Message, error) {
@@ -92,9 +107,28 @@ func (m *MessageRepositoryMock) SoftDeleteMessageForUser(ctx context.Context, me
 	return args.Error(0)
 }
 
-func (m *MessageRepositoryMock) DeleteMessageForAll(ctx context.Context, messageID int, userID int) error {
+func (m *MessageRepositoryMock) DeleteMessageForAll(ctx context.Context, messageID int, userID int) (bool, error) {
 	args := m.Called(ctx, messageID, userID)
-	return args.Error(0)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MessageRepositoryMock) EditMessage(ctx context.Context, messageID int, senderID int, content string) (models.Message, error) {
+	args := m.Called(ctx, messageID, senderID, content)
+	var msg models.Message
+	if val := args.Get(0); val != nil {
+		msg = val.(models.Message)
+	}
+	return msg, args.Error(1)
+}
+
+func (m *MessageRepositoryMock) AddReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error) {
+	args := m.Called(ctx, messageID, userID, emoji)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MessageRepositoryMock) RemoveReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error) {
+	args := m.Called(ctx, messageID, userID, emoji)
+	return args.Bool(0), args.Error(1)
 }
 
 type GroupRepositoryMock struct {
@@ -133,12 +167,102 @@ func (m *GroupRepositoryMock) GetGroup(ctx context.Context, groupID int) (models
 	return group, args.Error(1)
 }
 
+func (m *GroupRepositoryMock) ListGroupSummaries(ctx context.Context, userID int) ([]models.GroupSummary, error) {
+	args := m.Called(ctx, userID)
+	var groups []models.GroupSummary
+	if val := args.Get(0); val != nil {
+		groups = val.([]models.GroupSummary)
+	}
+	return groups, args.Error(1)
+}
+
+func (m *GroupRepositoryMock) MarkRead(ctx context.Context, groupID int, userID int, messageID int) (int, bool, error) {
+	args := m.Called(ctx, groupID, userID, messageID)
+	return args.Int(0), args.Bool(1), args.Error(2)
+}
+
+func (m *GroupRepositoryMock) AddMembers(ctx context.Context, groupID int, userIDs []int, addedBy int, note func(added []int) string) ([]int, models.GroupMessage, error) {
+	args := m.Called(ctx, groupID, userIDs, addedBy, note)
+	var added []int
+	if val := args.Get(0); val != nil {
+		added = val.([]int)
+	}
+	var msg models.GroupMessage
+	if val := args.Get(1); val != nil {
+		msg = val.(models.GroupMessage)
+	}
+	return added, msg, args.Error(2)
+}
+
+func (m *GroupRepositoryMock) RemoveMember(ctx context.Context, groupID int, userID int, expectedRole string, removedBy int, note string) (models.GroupMessage, error) {
+	args := m.Called(ctx, groupID, userID, expectedRole, removedBy, note)
+	var msg models.GroupMessage
+	if val := args.Get(0); val != nil {
+		msg = val.(models.GroupMessage)
+	}
+	return msg, args.Error(1)
+}
+
+func (m *GroupRepositoryMock) LeaveGroup(ctx context.Context, groupID int, userID int, note func(newOwnerID int) string) (int, models.GroupMessage, error) {
+	args := m.Called(ctx, groupID, userID, note)
+	var msg models.GroupMessage
+	if val := args.Get(1); val != nil {
+		msg = val.(models.GroupMessage)
+	}
+	return args.Int(0), msg, args.Error(2)
+}
+
+func (m *GroupRepositoryMock) GetMemberRole(ctx context.Context, groupID int, userID int) (string, error) {
+	args := m.Called(ctx, groupID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *GroupRepositoryMock) SetMemberRole(ctx context.Context, groupID int, userID int, expectedRole string, role string, changedBy int, note string) (models.GroupMessage, error) {
+	args := m.Called(ctx, groupID, userID, expectedRole, role, changedBy, note)
+	var msg models.GroupMessage
+	if val := args.Get(0); val != nil {
+		msg = val.(models.GroupMessage)
+	}
+	return msg, args.Error(1)
+}
+
+func (m *GroupRepositoryMock) UpdateGroup(ctx context.Context, groupID int, update repositories.GroupUpdate, updatedBy int, note string) (models.Group, models.GroupMessage, error) {
+	args := m.Called(ctx, groupID, update, updatedBy, note)
+	var group models.Group
+	if val := args.Get(0); val != nil {
+		group = val.(models.Group)
+	}
+	var msg models.GroupMessage
+	if val := args.Get(1); val != nil {
+		msg = val.(models.GroupMessage)
+	}
+	return group, msg, args.Error(2)
+}
+
+func (m *GroupRepositoryMock) ListGroupIDs(ctx context.Context, userID int) ([]int, error) {
+	args := m.Called(ctx, userID)
+	var ids []int
+	if val := args.Get(0); val != nil {
+		ids = val.([]int)
+	}
+	return ids, args.Error(1)
+}
+
+func (m *GroupRepositoryMock) ListMembers(ctx context.Context, groupID int) ([]models.GroupMember, error) {
+	args := m.Called(ctx, groupID)
+	var members []models.GroupMember
+	if val := args.Get(0); val != nil {
+		members = val.([]models.GroupMember)
+	}
+	return members, args.Error(1)
+}
+
 type GroupMessageRepositoryMock struct {
 	mock.Mock
 }
 
-func (m *GroupMessageRepositoryMock) CreateGroupMessage(ctx context.Context, groupID int, senderID int, content string) (models.GroupMessage, error) {
-	args := m.Called(ctx, groupID, senderID, content)
+func (m *GroupMessageRepositoryMock) CreateGroupMessage(ctx context.Context, groupID int, senderID int, content string, replyToID *int, attachmentIDs []int) (models.GroupMessage, error) {
+	args := m.Called(ctx, groupID, senderID, content, replyToID, attachmentIDs)
 	var msg models.GroupMessage
 	if val := args.Get(0); val != nil {
 		msg = val.(models.GroupMessage)
@@ -146,13 +270,13 @@ func (m *GroupMessageRepositoryMock) CreateGroupMessage(ctx context.Context, gro
 	return msg, args.Error(1)
 }
 
-func (m *GroupMessageRepositoryMock) ListGroupMessages(ctx context.Context, groupID int) ([]models.GroupMessage, error) {
-	args := m.Called(ctx, groupID)
+func (m *GroupMessageRepositoryMock) ListGroupMessages(ctx context.Context, groupID int, userID int, page repositories.PageRequest) ([]models.GroupMessage, int, error) {
+	args := m.Called(ctx, groupID, userID, page)
 	var msgs []models.GroupMessage
 	if val := args.Get(0); val != nil {
 		msgs = val.([]models.GroupMessage)
 	}
-	return msgs, args.Error(1)
+	return msgs, args.Int(1), args.Error(2)
 }
 
 func (m *GroupMessageRepositoryMock) GetGroupMessage(ctx context.Context, messageID int) (models.GroupMessage, error) {
@@ -164,9 +288,28 @@ func (m *GroupMessageRepositoryMock) GetGroupMessage(ctx context.Context, messag
 	return msg, args.Error(1)
 }
 
-func (m *GroupMessageRepositoryMock) DeleteForAll(ctx context.Context, messageID int, senderID int) error {
-	args := m.Called(ctx, messageID, senderID)
-	return args.Error(0)
+func (m *GroupMessageRepositoryMock) DeleteForAll(ctx context.Context, messageID int, deletedBy int) (bool, error) {
+	args := m.Called(ctx, messageID, deletedBy)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *GroupMessageRepositoryMock) EditGroupMessage(ctx context.Context, messageID int, senderID int, content string) (models.GroupMessage, error) {
+	args := m.Called(ctx, messageID, senderID, content)
+	var msg models.GroupMessage
+	if val := args.Get(0); val != nil {
+		msg = val.(models.GroupMessage)
+	}
+	return msg, args.Error(1)
+}
+
+func (m *GroupMessageRepositoryMock) AddReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error) {
+	args := m.Called(ctx, messageID, userID, emoji)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *GroupMessageRepositoryMock) RemoveReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error) {
+	args := m.Called(ctx, messageID, userID, emoji)
+	return args.Bool(0), args.Error(1)
 }
 
 type UserClientMock struct {
@@ -187,10 +330,123 @@ func (m *UserClientMock) BulkUsers(ctx context.Context, ids []int) ([]*userpb.Ge
 	return users, args.Error(1)
 }
 
+type PresenceRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *PresenceRepositoryMock) TouchLastSeen(ctx context.Context, userID int, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+func (m *PresenceRepositoryMock) LastSeen(ctx context.Context, userIDs []int) (map[int]time.Time, error) {
+	args := m.Called(ctx, userIDs)
+	var seen map[int]time.Time
+	if val := args.Get(0); val != nil {
+		seen = val.(map[int]time.Time)
+	}
+	return seen, args.Error(1)
+}
+
+func (m *PresenceRepositoryMock) ContactIDs(ctx context.Context, userID int) ([]int, error) {
+	args := m.Called(ctx, userID)
+	var ids []int
+	if val := args.Get(0); val != nil {
+		ids = val.([]int)
+	}
+	return ids, args.Error(1)
+}
+
+type SearchRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *SearchRepositoryMock) SearchMessages(ctx context.Context, userID int, query repositories.SearchQuery) ([]models.SearchResult, int, error) {
+	args := m.Called(ctx, userID, query)
+	var results []models.SearchResult
+	if val := args.Get(0); val != nil {
+		results = val.([]models.SearchResult)
+	}
+	return results, args.Int(1), args.Error(2)
+}
+
+type AttachmentRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *AttachmentRepositoryMock) CreateAttachment(ctx context.Context, attachment models.Attachment) (models.Attachment, error) {
+	args := m.Called(ctx, attachment)
+	var created models.Attachment
+	if val := args.Get(0); val != nil {
+		created = val.(models.Attachment)
+	}
+	return created, args.Error(1)
+}
+
+func (m *AttachmentRepositoryMock) GetAttachment(ctx context.Context, attachmentID int) (models.Attachment, error) {
+	args := m.Called(ctx, attachmentID)
+	var attachment models.Attachment
+	if val := args.Get(0); val != nil {
+		attachment = val.(models.Attachment)
+	}
+	return attachment, args.Error(1)
+}
+
+type UserEventRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *UserEventRepositoryMock) RestrictChat(ctx context.Context, eventID string, eventType string, userID int, otherID int, reason string) (int, error) {
+	args := m.Called(ctx, eventID, eventType, userID, otherID, reason)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *UserEventRepositoryMock) DeleteUserData(ctx context.Context, eventID string, eventType string, userID int) (repositories.UserDeletion, error) {
+	args := m.Called(ctx, eventID, eventType, userID)
+	var deletion repositories.UserDeletion
+	if val := args.Get(0); val != nil {
+		deletion = val.(repositories.UserDeletion)
+	}
+	return deletion, args.Error(1)
+}
+
+type BlockRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *BlockRepositoryMock) Block(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	args := m.Called(ctx, blockerID, blockedID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *BlockRepositoryMock) Unblock(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	args := m.Called(ctx, blockerID, blockedID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *BlockRepositoryMock) IsBlocked(ctx context.Context, userID int, otherID int) (bool, error) {
+	args := m.Called(ctx, userID, otherID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *BlockRepositoryMock) BlockedUsers(ctx context.Context, userID int) ([]int, error) {
+	args := m.Called(ctx, userID)
+	var blockedIDs []int
+	if val := args.Get(0); val != nil {
+		blockedIDs = val.([]int)
+	}
+	return blockedIDs, args.Error(1)
+}
+
 var _ repositories.ChatRepository = (*ChatRepositoryMock)(nil)
 var _ repositories.MessageRepository = (*MessageRepositoryMock)(nil)
 var _ repositories.GroupRepository = (*GroupRepositoryMock)(nil)
 var _ repositories.GroupMessageRepository = (*GroupMessageRepositoryMock)(nil)
+var _ repositories.PresenceRepository = (*PresenceRepositoryMock)(nil)
+var _ repositories.SearchRepository = (*SearchRepositoryMock)(nil)
+var _ repositories.AttachmentRepository = (*AttachmentRepositoryMock)(nil)
+var _ repositories.UserEventRepository = (*UserEventRepositoryMock)(nil)
+var _ repositories.BlockRepository = (*BlockRepositoryMock)(nil)
 var _ interface {
 	AreFriends(context.Context, int, int) (bool, error)
 	BulkUsers(context.Context, []int) ([]*userpb.GetUserResponse, error)
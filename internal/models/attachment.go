@@ -0,0 +1,32 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// Attachment scopes, naming the table of the message an attachment belongs to.
+const (
+	AttachmentScopeChat  = "chat"
+	AttachmentScopeGroup = "group"
+)
+
+// Attachment is a file uploaded by a user and optionally linked to one chat or group message.
+// Its content lives in blob storage and is served from URL after an access check.
+type Attachment struct {
+	ID          int       `db:"id" json:"id"`
+	UploaderID  int       `db:"uploader_id" json:"-"`
+	StorageKey  string    `db:"storage_key" json:"-"`
+	FileName    string    `db:"file_name" json:"file_name"`
+	ContentType string    `db:"content_type" json:"content_type"`
+	Size        int64     `db:"size_bytes" json:"size"`
+	Scope       *string   `db:"scope" json:"-"`
+	MessageID   *int      `db:"message_id" json:"-"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	URL         string    `db:"-" json:"url"`
+}
+
+// AttachmentURL is the download path of an attachment.
+func AttachmentURL(id int) string {
+	return "/attachments/" + strconv.Itoa(id)
+}
@@ -2,19 +2,36 @@ package models
 
 import "time"
 
+// Reasons a chat stops accepting new messages.
+const (
+	ChatReadOnlyUnfriended     = "unfriended"
+	ChatReadOnlyBlocked        = "blocked"
+	ChatReadOnlyAccountDeleted = "account_deleted"
+)
+
+// DeletedUserID replaces the sender of group messages whose author deleted their account.
+const DeletedUserID = 0
+
 // Chat represents a private chat between exactly two users.
 type Chat struct {
 	ID        int       `db:"id" json:"id"`
 	User1ID   int       `db:"user1_id" json:"user1_id"`
 	User2ID   int       `db:"user2_id" json:"user2_id"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	// ReadOnlyReason is set when the chat no longer accepts messages.
+	ReadOnlyReason *string `db:"read_only_reason" json:"read_only_reason,omitempty"`
 }
 
 // ChatSummary provides API-friendly view of a chat for a user.
 type ChatSummary struct {
-	ChatID   int       `db:"id" json:"chat_id"`
-	FriendID int       `json:"friend_id"`
-	Created  time.Time `db:"created_at" json:"created_at"`
+	ChatID            int             `db:"id" json:"chat_id"`
+	FriendID          int             `json:"friend_id"`
+	Created           time.Time       `db:"created_at" json:"created_at"`
+	LastReadMessageID int             `db:"last_read_message_id" json:"last_read_message_id"`
+	UnreadCount       int             `db:"unread_count" json:"unread_count"`
+	LastMessage       *MessagePreview `json:"last_message,omitempty"`
+	LastActivityAt    time.Time       `json:"last_activity_at"`
+	ReadOnlyReason    *string         `json:"read_only_reason,omitempty"`
 }
 
 // ChatVisibility models per-user chat visibility state.
@@ -4,25 +4,75 @@ import "time"
 
 // Group represents a chat group.
 type Group struct {
-	ID        int       `db:"id" json:"id"`
-	Name      string    `db:"name" json:"name"`
-	OwnerID   int       `db:"owner_id" json:"owner_id"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	ID          int       `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	AvatarURL   string    `db:"avatar_url" json:"avatar_url"`
+	OwnerID     int       `db:"owner_id" json:"owner_id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
 }
 
+// Limits applied to group settings.
+const (
+	GroupNameMaxRunes        = 100
+	GroupDescriptionMaxRunes = 500
+	GroupAvatarURLMaxLength  = 2048
+)
+
+// GroupMember is a group membership with the member's role.
+type GroupMember struct {
+	UserID   int       `db:"user_id" json:"user_id"`
+	Username string    `db:"-" json:"username,omitempty"`
+	Role     string    `db:"role" json:"role"`
+	JoinedAt time.Time `db:"joined_at" json:"joined_at"`
+}
+
+// Group member roles, from most to least privileged.
+const (
+	GroupRoleOwner  = "owner"
+	GroupRoleAdmin  = "admin"
+	GroupRoleMember = "member"
+)
+
+// GroupSummary extends a group with the caller's read state.
+type GroupSummary struct {
+	Group
+	LastReadMessageID int             `db:"last_read_message_id" json:"last_read_message_id"`
+	UnreadCount       int             `db:"unread_count" json:"unread_count"`
+	LastMessage       *MessagePreview `db:"-" json:"last_message,omitempty"`
+	LastActivityAt    time.Time       `db:"last_activity_at" json:"last_activity_at"`
+}
+
+// Group message kinds. System messages record membership changes in the group timeline.
+const (
+	GroupMessageKindUser   = "user"
+	GroupMessageKindSystem = "system"
+)
+
 // GroupMessage represents a message sent in a group.
 type GroupMessage struct {
-	ID            int       `db:"id" json:"id"`
-	GroupID       int       `db:"group_id" json:"group_id"`
-	SenderID      int       `db:"sender_id" json:"sender_id"`
-	Content       string    `db:"content" json:"content"`
-	DeletedForAll bool      `db:"deleted_for_all" json:"deleted_for_all"`
-	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	ID               int               `db:"id" json:"id"`
+	GroupID          int               `db:"group_id" json:"group_id"`
+	SenderID         int               `db:"sender_id" json:"sender_id"`
+	Content          string            `db:"content" json:"content"`
+	Kind             string            `db:"kind" json:"kind"`
+	DeletedForAll    bool              `db:"deleted_for_all" json:"deleted_for_all"`
+	CreatedAt        time.Time         `db:"created_at" json:"created_at"`
+	EditedAt         *time.Time        `db:"edited_at" json:"edited_at,omitempty"`
+	ReplyToMessageID *int              `db:"reply_to_message_id" json:"reply_to_message_id,omitempty"`
+	ReplyTo          *QuotedMessage    `db:"-" json:"reply_to,omitempty"`
+	Reactions        []ReactionSummary `db:"-" json:"reactions,omitempty"`
+	Attachments      []Attachment      `db:"-" json:"attachments,omitempty"`
 }
 
 // GroupEvent is emitted over WebSocket connections for groups.
 type GroupEvent struct {
 	Type      string        `json:"type"`
+	GroupID   int           `json:"group_id,omitempty"`
+	Seq       int64         `json:"seq,omitempty"`
 	Message   *GroupMessage `json:"message,omitempty"`
 	MessageID int           `json:"message_id,omitempty"`
+	Reaction  *Reaction     `json:"reaction,omitempty"`
+	UserID    int           `json:"user_id,omitempty"`
+	Group     *Group        `json:"group,omitempty"`
 }
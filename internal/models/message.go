@@ -4,19 +4,69 @@ import "time"
 
 // Message represents a chat message.
 type Message struct {
-	ID                int       `db:"id" json:"id"`
-	ChatID            int       `db:"chat_id" json:"chat_id"`
-	SenderID          int       `db:"sender_id" json:"sender_id"`
-	Content           string    `db:"content" json:"content"`
-	DeletedBySender   bool      `db:"deleted_by_sender" json:"deleted_by_sender"`
-	DeletedByReceiver bool      `db:"deleted_by_receiver" json:"deleted_by_receiver"`
-	DeletedForAll     bool      `db:"deleted_for_all" json:"deleted_for_all"`
-	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	ID                int               `db:"id" json:"id"`
+	ChatID            int               `db:"chat_id" json:"chat_id"`
+	SenderID          int               `db:"sender_id" json:"sender_id"`
+	Content           string            `db:"content" json:"content"`
+	DeletedBySender   bool              `db:"deleted_by_sender" json:"deleted_by_sender"`
+	DeletedByReceiver bool              `db:"deleted_by_receiver" json:"deleted_by_receiver"`
+	DeletedForAll     bool              `db:"deleted_for_all" json:"deleted_for_all"`
+	CreatedAt         time.Time         `db:"created_at" json:"created_at"`
+	EditedAt          *time.Time        `db:"edited_at" json:"edited_at,omitempty"`
+	ReplyToMessageID  *int              `db:"reply_to_message_id" json:"reply_to_message_id,omitempty"`
+	ReplyTo           *QuotedMessage    `db:"-" json:"reply_to,omitempty"`
+	Reactions         []ReactionSummary `db:"-" json:"reactions,omitempty"`
+	Attachments       []Attachment      `db:"-" json:"attachments,omitempty"`
+}
+
+// QuotedMessageMaxRunes bounds the content kept in a quoted message snapshot.
+const QuotedMessageMaxRunes = 120
+
+// QuotedMessage is a compact snapshot of the message a reply refers to.
+// Deleted messages, and messages hidden from the viewer, are returned as tombstones without content.
+type QuotedMessage struct {
+	ID       int    `json:"id"`
+	SenderID int    `json:"sender_id"`
+	Content  string `json:"content,omitempty"`
+	Deleted  bool   `json:"deleted,omitempty"`
+}
+
+// NewQuotedMessage builds a snapshot, truncating the content and hiding it for deleted messages.
+func NewQuotedMessage(id, senderID int, content string, deleted bool) *QuotedMessage {
+	if deleted {
+		return &QuotedMessage{ID: id, SenderID: senderID, Deleted: true}
+	}
+	return &QuotedMessage{ID: id, SenderID: senderID, Content: Snippet(content, QuotedMessageMaxRunes)}
+}
+
+// PreviewMaxRunes bounds the snippet shown in conversation list previews.
+const PreviewMaxRunes = 100
+
+// MessagePreview summarises the latest visible message of a conversation.
+type MessagePreview struct {
+	ID        int       `json:"id"`
+	SenderID  int       `json:"sender_id"`
+	Snippet   string    `json:"snippet"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Snippet truncates content to maxRunes characters, appending an ellipsis when shortened.
+func Snippet(content string, maxRunes int) string {
+	runes := []rune(content)
+	if len(runes) <= maxRunes {
+		return content
+	}
+	return string(runes[:maxRunes]) + "…"
 }
 
 // ChatEvent is broadcasted through websockets.
 type ChatEvent struct {
-	Type      string   `json:"type"`
-	Message   *Message `json:"message,omitempty"`
-	MessageID int      `json:"message_id,omitempty"`
+	Type      string    `json:"type"`
+	ChatID    int       `json:"chat_id,omitempty"`
+	Seq       int64     `json:"seq,omitempty"`
+	Message   *Message  `json:"message,omitempty"`
+	MessageID int       `json:"message_id,omitempty"`
+	Reaction  *Reaction `json:"reaction,omitempty"`
+	UserID    int       `json:"user_id,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
 }
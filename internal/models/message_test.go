@@ -0,0 +1,20 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewQuotedMessageTruncatesContent(t *testing.T) {
+	quote := NewQuotedMessage(1, 2, strings.Repeat("é", QuotedMessageMaxRunes+10), false)
+	if got := len([]rune(quote.Content)); got != QuotedMessageMaxRunes+1 {
+		t.Fatalf("expected %d runes including ellipsis, got %d", QuotedMessageMaxRunes+1, got)
+	}
+}
+
+func TestNewQuotedMessageTombstone(t *testing.T) {
+	quote := NewQuotedMessage(1, 2, "secret", true)
+	if !quote.Deleted || quote.Content != "" {
+		t.Fatalf("expected tombstone without content, got %+v", quote)
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Presence describes whether a user is connected and when they were last seen.
+// LastSeenAt is omitted while the user is online.
+type Presence struct {
+	UserID     int        `json:"user_id"`
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// PresenceEvent is pushed to a user's contacts when the user comes online or goes offline.
+type PresenceEvent struct {
+	Type string `json:"type"`
+	Presence
+}
@@ -0,0 +1,15 @@
+package models
+
+// Reaction is a single user's emoji reaction to a message.
+type Reaction struct {
+	MessageID int    `json:"message_id"`
+	UserID    int    `json:"user_id"`
+	Emoji     string `json:"emoji"`
+}
+
+// ReactionSummary aggregates the reactions with the same emoji on a message.
+type ReactionSummary struct {
+	Emoji       string `db:"emoji" json:"emoji"`
+	Count       int    `db:"count" json:"count"`
+	ReactedByMe bool   `db:"reacted_by_me" json:"reacted_by_me"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Search scopes.
+const (
+	SearchScopeChat  = "chat"
+	SearchScopeGroup = "group"
+)
+
+// SearchResult is a message matching a full-text search. Exactly one of ChatID and GroupID is set.
+// Snippet is HTML-escaped message text with the matched terms wrapped in <mark> tags.
+type SearchResult struct {
+	Scope          string    `db:"scope" json:"scope"`
+	MessageID      int       `db:"message_id" json:"message_id"`
+	ChatID         *int      `db:"chat_id" json:"chat_id,omitempty"`
+	GroupID        *int      `db:"group_id" json:"group_id,omitempty"`
+	SenderID       int       `db:"sender_id" json:"sender_id"`
+	SenderUsername string    `db:"-" json:"sender_username,omitempty"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	Snippet        string    `db:"snippet" json:"snippet"`
+}
@@ -0,0 +1,57 @@
+// Package outbox makes domain event publishing reliable. Events are stored in the outbox table in
+// the same transaction as the change they describe, and a Relay publishes them afterwards,
+// retrying until the broker accepts them.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+
+	"chat-service/internal/telemetry"
+)
+
+// Aggregate returns the key grouping events that must be published in order, e.g. every event
+// of one conversation.
+func Aggregate(kind string, id int) string {
+	return kind + ":" + strconv.Itoa(id)
+}
+
+// aggregateLockClass is the first key of the transaction-level advisory locks that serialize
+// writers of one aggregate. The two-key lock space does not overlap the relay's single-key lock.
+const aggregateLockClass = 0x61676772 // "aggr"
+
+// Writer stores domain events in the outbox. A nil Writer discards them.
+type Writer struct {
+	source *telemetry.EventSource
+}
+
+// NewWriter returns a Writer wrapping events in envelopes from source.
+func NewWriter(source *telemetry.EventSource) *Writer {
+	return &Writer{source: source}
+}
+
+// Add stores event within tx, on behalf of actorID. The request id is taken from ctx.
+//
+// Add first locks the aggregate until tx ends, so concurrent transactions writing events of one
+// aggregate commit one after the other and their ids follow commit order. The relay therefore
+// never sees an event of an aggregate while an earlier one is still uncommitted.
+func (w *Writer) Add(ctx context.Context, tx sqlx.ExecerContext, aggregate string, event telemetry.DomainEvent, actorID int) error {
+	if w == nil {
+		return nil
+	}
+
+	envelope := w.source.NewEnvelope(event, telemetry.RequestIDFromContext(ctx), actorID)
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, aggregateLockClass, aggregate); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO outbox (aggregate, routing_key, payload) VALUES ($1, $2, $3)`,
+		aggregate, w.source.RoutingKey(envelope.EventType), payload)
+	return err
+}
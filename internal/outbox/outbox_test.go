@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"chat-service/internal/events"
+	"chat-service/internal/telemetry"
+)
+
+type recordingExecer struct {
+	queries []string
+	args    [][]any
+}
+
+func (e *recordingExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	e.queries = append(e.queries, query)
+	e.args = append(e.args, args)
+	return nil, nil
+}
+
+func TestWriterAddStoresEnvelope(t *testing.T) {
+	writer := NewWriter(telemetry.NewEventSource("chat-service", "chat-service", "local"))
+	exec := &recordingExecer{}
+	ctx := telemetry.ContextWithRequestID(context.Background(), "req-1")
+
+	err := writer.Add(ctx, exec, Aggregate(events.ScopeChat, 5), events.MessageDeletedForAll{Scope: events.ScopeChat, MessageID: 7, ChatID: 5, DeletedBy: 1}, 1)
+	require.NoError(t, err)
+
+	require.Len(t, exec.queries, 2)
+	assert.Contains(t, exec.queries[0], "pg_advisory_xact_lock")
+	assert.Equal(t, []any{aggregateLockClass, "chat:5"}, exec.args[0])
+
+	args := exec.args[1]
+	require.Len(t, args, 3)
+	assert.Equal(t, "chat:5", args[0])
+	assert.Equal(t, "chat-service.message.deleted_for_all", args[1])
+
+	var envelope struct {
+		EventType string `json:"event_type"`
+		RequestID string `json:"request_id"`
+		UserID    int64  `json:"user_id"`
+		Payload   struct {
+			MessageID int `json:"message_id"`
+		} `json:"payload"`
+	}
+	require.NoError(t, json.Unmarshal(args[2].([]byte), &envelope))
+	assert.Equal(t, "message.deleted_for_all", envelope.EventType)
+	assert.Equal(t, "req-1", envelope.RequestID)
+	assert.Equal(t, int64(1), envelope.UserID)
+	assert.Equal(t, 7, envelope.Payload.MessageID)
+}
+
+func TestNilWriterDiscardsEvents(t *testing.T) {
+	var writer *Writer
+	exec := &recordingExecer{}
+
+	require.NoError(t, writer.Add(context.Background(), exec, "chat:5", events.ChatStarted{ChatID: 5}, 1))
+	assert.Empty(t, exec.queries)
+}
@@ -0,0 +1,205 @@
+package outbox
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// relayLockID is the session-level advisory lock held while a batch is published, so only
+// one replica relays at a time and per-aggregate order is kept.
+const relayLockID = 0x6f757462 // "outb"
+
+// Publisher delivers an event to the broker under routingKey.
+type Publisher interface {
+	Publish(ctx context.Context, routingKey string, event any) error
+}
+
+// RelayConfig tunes the relay.
+type RelayConfig struct {
+	// PollInterval is the pause between batches while the outbox is drained.
+	PollInterval time.Duration
+	// BatchSize bounds the events picked up per batch.
+	BatchSize int
+	// PublishTimeout bounds a single publish.
+	PublishTimeout time.Duration
+	// MaxBackoff caps the delay before retrying a failed event; retries start at one second and double.
+	MaxBackoff time.Duration
+	// Retention is how long delivered events are kept before cleanup.
+	Retention time.Duration
+}
+
+// DefaultRelayConfig returns the relay settings used in production.
+func DefaultRelayConfig() RelayConfig {
+	return RelayConfig{
+		PollInterval:   time.Second,
+		BatchSize:      100,
+		PublishTimeout: 5 * time.Second,
+		MaxBackoff:     5 * time.Minute,
+		Retention:      24 * time.Hour,
+	}
+}
+
+// Relay publishes pending outbox events in id order. Events of one aggregate are never
+// published out of order: when one fails, the later ones wait until it is retried successfully.
+type Relay struct {
+	db        *sqlx.DB
+	publisher Publisher
+	cfg       RelayConfig
+}
+
+// NewRelay constructs a Relay.
+func NewRelay(db *sqlx.DB, publisher Publisher, cfg RelayConfig) *Relay {
+	return &Relay{db: db, publisher: publisher, cfg: cfg}
+}
+
+type pendingEvent struct {
+	ID         int64           `db:"id"`
+	Aggregate  string          `db:"aggregate"`
+	RoutingKey string          `db:"routing_key"`
+	Payload    json.RawMessage `db:"payload"`
+	Attempts   int             `db:"attempts"`
+}
+
+// Run relays events until ctx is cancelled. Batches follow each other without pause while the
+// outbox is full; delivered events are cleaned up once per Retention/24.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	cleanupEvery := r.cfg.Retention / 24
+	lastCleanup := time.Time{}
+
+	for {
+		for {
+			published, err := r.relayBatch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("outbox relay failed: %v", err)
+				}
+				break
+			}
+			if published < r.cfg.BatchSize {
+				break
+			}
+		}
+		if time.Since(lastCleanup) >= cleanupEvery {
+			if err := r.cleanup(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("outbox cleanup failed: %v", err)
+			}
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayBatch publishes one batch of due events, recording each outcome as soon as the broker
+// answers, so no transaction stays open across publishes. It returns the number of events
+// picked up, or zero when another replica holds the relay lock.
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock($1)`, relayLockID); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+	defer r.unlock(conn)
+
+	// Skip every event queued behind an earlier one of its aggregate that is still waiting for a retry.
+	var batch []pendingEvent
+	if err := conn.SelectContext(ctx, &batch, `SELECT id, aggregate, routing_key, payload, attempts FROM outbox o
+        WHERE delivered_at IS NULL AND next_attempt_at <= NOW()
+        AND NOT EXISTS (
+            SELECT 1 FROM outbox p
+            WHERE p.aggregate = o.aggregate AND p.delivered_at IS NULL AND p.id < o.id AND p.next_attempt_at > NOW()
+        )
+        ORDER BY id
+        LIMIT $1`, r.cfg.BatchSize); err != nil {
+		return 0, err
+	}
+
+	err = r.publish(ctx, batch, func(event pendingEvent, publishErr error) error {
+		return r.settle(ctx, conn, event, publishErr)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(batch), nil
+}
+
+// settle records the outcome of publishing event: delivered, or due for a retry after backoff.
+func (r *Relay) settle(ctx context.Context, db sqlx.ExecerContext, event pendingEvent, publishErr error) error {
+	if publishErr == nil {
+		_, err := db.ExecContext(ctx, `UPDATE outbox SET delivered_at = NOW() WHERE id = $1`, event.ID)
+		return err
+	}
+	_, err := db.ExecContext(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $2,
+        next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond' WHERE id = $1`,
+		event.ID, publishErr.Error(), r.backoff(event.Attempts+1).Milliseconds())
+	return err
+}
+
+// unlock releases the relay lock. When that fails the connection is discarded instead of going
+// back to the pool, which ends its session and releases the lock with it.
+func (r *Relay) unlock(conn *sqlx.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.PublishTimeout)
+	defer cancel()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, relayLockID); err != nil {
+		log.Printf("outbox relay unlock failed, discarding connection: %v", err)
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+}
+
+// publish sends batch in order and hands each outcome to settle. After an event fails, the rest
+// of its aggregate is held back and not settled. An error from settle stops the batch.
+func (r *Relay) publish(ctx context.Context, batch []pendingEvent, settle func(event pendingEvent, err error) error) error {
+	blocked := map[string]bool{}
+	for _, event := range batch {
+		if blocked[event.Aggregate] {
+			continue
+		}
+		publishCtx, cancel := context.WithTimeout(ctx, r.cfg.PublishTimeout)
+		err := r.publisher.Publish(publishCtx, event.RoutingKey, event.Payload)
+		cancel()
+		if err != nil {
+			blocked[event.Aggregate] = true
+		}
+		if err := settle(event, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backoff returns the retry delay after the given number of failed attempts.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempts && delay < r.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.cfg.MaxBackoff {
+		delay = r.cfg.MaxBackoff
+	}
+	return delay
+}
+
+// cleanup deletes events delivered longer than Retention ago.
+func (r *Relay) cleanup(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM outbox WHERE delivered_at < NOW() - $1 * INTERVAL '1 millisecond'`, r.cfg.Retention.Milliseconds())
+	return err
+}
@@ -0,0 +1,83 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePublisher struct {
+	fail      map[string]bool
+	published []string
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, routingKey string, event any) error {
+	if p.fail[routingKey] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, routingKey)
+	return nil
+}
+
+func TestRelayPublishHoldsBackAggregateAfterFailure(t *testing.T) {
+	publisher := &fakePublisher{fail: map[string]bool{"chat.2": true}}
+	relay := NewRelay(nil, publisher, DefaultRelayConfig())
+
+	batch := []pendingEvent{
+		{ID: 1, Aggregate: "chat:5", RoutingKey: "chat.1", Payload: json.RawMessage(`{}`)},
+		{ID: 2, Aggregate: "chat:5", RoutingKey: "chat.2", Payload: json.RawMessage(`{}`)},
+		{ID: 3, Aggregate: "group:9", RoutingKey: "group.1", Payload: json.RawMessage(`{}`)},
+		{ID: 4, Aggregate: "chat:5", RoutingKey: "chat.3", Payload: json.RawMessage(`{}`)},
+		{ID: 5, Aggregate: "group:9", RoutingKey: "group.2", Payload: json.RawMessage(`{}`)},
+	}
+	var (
+		delivered []int64
+		failed    []int64
+	)
+	err := relay.publish(context.Background(), batch, func(event pendingEvent, err error) error {
+		if err != nil {
+			assert.EqualError(t, err, "broker unavailable")
+			failed = append(failed, event.ID)
+		} else {
+			delivered = append(delivered, event.ID)
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 3, 5}, delivered)
+	assert.Equal(t, []int64{2}, failed)
+	assert.Equal(t, []string{"chat.1", "group.1", "group.2"}, publisher.published)
+}
+
+func TestRelayPublishStopsWhenSettleFails(t *testing.T) {
+	publisher := &fakePublisher{}
+	relay := NewRelay(nil, publisher, DefaultRelayConfig())
+
+	batch := []pendingEvent{
+		{ID: 1, Aggregate: "chat:5", RoutingKey: "chat.1", Payload: json.RawMessage(`{}`)},
+		{ID: 2, Aggregate: "group:9", RoutingKey: "group.1", Payload: json.RawMessage(`{}`)},
+	}
+	err := relay.publish(context.Background(), batch, func(event pendingEvent, err error) error {
+		return errors.New("database unavailable")
+	})
+
+	assert.EqualError(t, err, "database unavailable")
+	assert.Equal(t, []string{"chat.1"}, publisher.published)
+}
+
+func TestRelayBackoffDoublesUpToMax(t *testing.T) {
+	cfg := DefaultRelayConfig()
+	cfg.MaxBackoff = time.Minute
+	relay := NewRelay(nil, &fakePublisher{}, cfg)
+
+	assert.Equal(t, time.Second, relay.backoff(1))
+	assert.Equal(t, 2*time.Second, relay.backoff(2))
+	assert.Equal(t, 32*time.Second, relay.backoff(6))
+	assert.Equal(t, time.Minute, relay.backoff(7))
+	assert.Equal(t, time.Minute, relay.backoff(50))
+}
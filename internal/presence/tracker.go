@@ -0,0 +1,152 @@
+package presence
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"chat-service/internal/models"
+	"chat-service/internal/repositories"
+	"chat-service/internal/ws"
+)
+
+// DefaultOfflineGrace delays offline transitions so that brief reconnects (network handover,
+// app restarts) do not flap a user's presence.
+const DefaultOfflineGrace = 5 * time.Second
+
+// storeTimeout bounds the database work done for a single transition.
+const storeTimeout = 5 * time.Second
+
+// Tracker turns the hub's connection transitions into user presence. It persists last-seen
+// timestamps and pushes presence events to the user's contacts. Register it with
+// ws.Hub.SetPresenceListener. With several instances, a user goes online when their first
+// connection on any instance opens and offline once no instance holds a connection.
+type Tracker struct {
+	repo  repositories.PresenceRepository
+	hub   *ws.Hub
+	grace time.Duration
+
+	mu      sync.Mutex
+	pending map[int]*time.Timer
+}
+
+// NewTracker constructs a Tracker.
+func NewTracker(repo repositories.PresenceRepository, hub *ws.Hub) *Tracker {
+	return &Tracker{
+		repo:    repo,
+		hub:     hub,
+		grace:   DefaultOfflineGrace,
+		pending: make(map[int]*time.Timer),
+	}
+}
+
+// SetOfflineGrace overrides how long a user may stay disconnected before going offline.
+func (t *Tracker) SetOfflineGrace(grace time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.grace = grace
+}
+
+// UserConnected implements ws.PresenceListener.
+func (t *Tracker) UserConnected(userID int) {
+	t.mu.Lock()
+	timer, reconnected := t.pending[userID]
+	if reconnected {
+		// back within the grace period: the user never went offline
+		timer.Stop()
+		delete(t.pending, userID)
+	}
+	t.mu.Unlock()
+
+	// already online through another instance, which published the transition
+	if !reconnected && !t.hub.OnlineElsewhere(userID) {
+		go t.publish(userID, true, time.Now())
+	}
+}
+
+// UserDisconnected implements ws.PresenceListener.
+func (t *Tracker) UserDisconnected(userID int) {
+	seenAt := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if timer, ok := t.pending[userID]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(t.grace, func() {
+		t.mu.Lock()
+		if t.pending[userID] != timer {
+			t.mu.Unlock()
+			return
+		}
+		delete(t.pending, userID)
+		t.mu.Unlock()
+
+		if !t.hub.Online(userID) {
+			t.publish(userID, false, seenAt)
+		}
+	})
+	t.pending[userID] = timer
+}
+
+// Online reports whether the user is online. Users inside the offline grace period still count.
+func (t *Tracker) Online(userID int) bool {
+	if t.hub.Online(userID) {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, pending := t.pending[userID]
+	return pending
+}
+
+// Lookup returns the presence of each user, in the order given.
+func (t *Tracker) Lookup(ctx context.Context, userIDs []int) ([]models.Presence, error) {
+	var offline []int
+	result := make([]models.Presence, 0, len(userIDs))
+	for _, id := range userIDs {
+		online := t.Online(id)
+		result = append(result, models.Presence{UserID: id, Online: online})
+		if !online {
+			offline = append(offline, id)
+		}
+	}
+	if len(offline) == 0 {
+		return result, nil
+	}
+
+	seen, err := t.repo.LastSeen(ctx, offline)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		if at, ok := seen[result[i].UserID]; ok && !result[i].Online {
+			at := at
+			result[i].LastSeenAt = &at
+		}
+	}
+	return result, nil
+}
+
+// publish persists the transition and notifies the user's contacts.
+func (t *Tracker) publish(userID int, online bool, at time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := t.repo.TouchLastSeen(ctx, userID, at); err != nil {
+		log.Printf("presence: store last seen user_id=%d: %v", userID, err)
+	}
+
+	contacts, err := t.repo.ContactIDs(ctx, userID)
+	if err != nil {
+		log.Printf("presence: load contacts user_id=%d: %v", userID, err)
+		return
+	}
+	event := models.PresenceEvent{Type: "presence", Presence: models.Presence{UserID: userID, Online: online}}
+	if !online {
+		event.LastSeenAt = &at
+	}
+	t.hub.PushToUsers(contacts, event)
+}
@@ -0,0 +1,88 @@
+package presence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"chat-service/internal/mocks"
+	"chat-service/internal/ws"
+)
+
+func TestTrackerReconnectWithinGraceStaysOnline(t *testing.T) {
+	repo := new(mocks.PresenceRepositoryMock)
+	hub := ws.NewHub()
+	tracker := NewTracker(repo, hub)
+	tracker.SetOfflineGrace(time.Hour)
+	hub.SetPresenceListener(tracker)
+
+	online := make(chan struct{}, 2)
+	repo.On("TouchLastSeen", mock.Anything, 10, mock.Anything).Return(nil)
+	repo.On("ContactIDs", mock.Anything, 10).Return([]int{}, nil).Run(func(mock.Arguments) { online <- struct{}{} })
+
+	client := hub.AddChatClient(1, 10, nil)
+	<-online
+	hub.RemoveChatClient(1, client)
+	require.True(t, tracker.Online(10), "user stays online during the grace period")
+
+	hub.AddGroupClient(2, 10, nil)
+	require.True(t, tracker.Online(10))
+	select {
+	case <-online:
+		t.Fatalf("reconnect within the grace period must not publish presence again")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTrackerGoesOfflineAfterGrace(t *testing.T) {
+	repo := new(mocks.PresenceRepositoryMock)
+	hub := ws.NewHub()
+	tracker := NewTracker(repo, hub)
+	tracker.SetOfflineGrace(10 * time.Millisecond)
+	hub.SetPresenceListener(tracker)
+
+	published := make(chan struct{}, 2)
+	repo.On("TouchLastSeen", mock.Anything, 10, mock.Anything).Return(nil)
+	repo.On("ContactIDs", mock.Anything, 10).Return([]int{11}, nil).Run(func(mock.Arguments) { published <- struct{}{} })
+
+	client := hub.AddChatClient(1, 10, nil)
+	<-published
+	hub.RemoveChatClient(1, client)
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatalf("expected offline transition to be published")
+	}
+	require.False(t, tracker.Online(10))
+	repo.AssertNumberOfCalls(t, "TouchLastSeen", 2)
+}
+
+func TestTrackerStaysOnlineWhileAnotherInstanceHoldsAConnection(t *testing.T) {
+	repo := new(mocks.PresenceRepositoryMock)
+	backplane := ws.NewMemoryBackplane()
+	local, remote := ws.NewHub(), ws.NewHub()
+	require.NoError(t, local.SetBackplane(backplane))
+	require.NoError(t, remote.SetBackplane(backplane))
+	tracker := NewTracker(repo, local)
+	tracker.SetOfflineGrace(10 * time.Millisecond)
+	local.SetPresenceListener(tracker)
+
+	published := make(chan struct{}, 2)
+	repo.On("TouchLastSeen", mock.Anything, 10, mock.Anything).Return(nil)
+	repo.On("ContactIDs", mock.Anything, 10).Return([]int{11}, nil).Run(func(mock.Arguments) { published <- struct{}{} })
+
+	remote.AddGroupClient(2, 10, nil)
+	require.True(t, tracker.Online(10), "connections on other instances count")
+
+	client := local.AddChatClient(1, 10, nil)
+	local.RemoveChatClient(1, client)
+	select {
+	case <-published:
+		t.Fatalf("presence must not change while another instance holds a connection")
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.True(t, tracker.Online(10))
+}
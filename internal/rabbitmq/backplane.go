@@ -0,0 +1,161 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Reconnection backoff of the backplane, doubling after each failed attempt.
+const (
+	backplaneMinBackoff = 500 * time.Millisecond
+	backplaneMaxBackoff = 30 * time.Second
+)
+
+// Backplane relays websocket hub operations between service instances through a fanout
+// exchange. Every instance consumes from its own exclusive queue, which is deleted when the
+// instance disconnects; operations published while an instance is disconnected are not kept
+// for it. The backplane reconnects when the broker is unreachable, at startup or later, and
+// Publish fails with ErrNotConnected meanwhile.
+type Backplane struct {
+	amqpURL  string
+	exchange string
+
+	mu        sync.Mutex
+	publishCh *amqp.Channel // nil while disconnected
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewBackplane constructs a Backplane; it connects once Subscribe is called.
+func NewBackplane(amqpURL, exchange string) *Backplane {
+	return &Backplane{amqpURL: amqpURL, exchange: exchange}
+}
+
+// Publish sends payload to every instance, including this one.
+func (b *Backplane) Publish(ctx context.Context, payload []byte) error {
+	b.mu.Lock()
+	ch := b.publishCh
+	b.mu.Unlock()
+	if ch == nil {
+		return ErrNotConnected
+	}
+	return ch.PublishWithContext(ctx, b.exchange, "", false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Transient,
+		Body:         payload,
+	})
+}
+
+// Subscribe starts consuming in the background and passes each payload to handler from a
+// single goroutine, in the order received. Each connection declares the exchange and a fresh
+// exclusive queue bound to it.
+func (b *Backplane) Subscribe(handler func(payload []byte)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancel != nil {
+		return errors.New("rabbitmq backplane already subscribed")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	b.done = make(chan struct{})
+	go b.run(ctx, handler)
+	return nil
+}
+
+// run consumes until ctx is cancelled, reconnecting with exponential backoff.
+func (b *Backplane) run(ctx context.Context, handler func(payload []byte)) {
+	defer close(b.done)
+	backoff := backplaneMinBackoff
+	for {
+		consumed, err := b.consume(ctx, handler)
+		if ctx.Err() != nil {
+			return
+		}
+		if consumed {
+			backoff = backplaneMinBackoff
+		}
+		log.Printf("rabbitmq backplane disconnected exchange=%s, reconnecting in %s: %v", b.exchange, backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, backplaneMaxBackoff)
+	}
+}
+
+// consume connects, declares the exchange and an exclusive queue, and handles deliveries until
+// the connection drops or ctx is cancelled. It reports whether consuming started.
+func (b *Backplane) consume(ctx context.Context, handler func(payload []byte)) (bool, error) {
+	conn, err := amqp.Dial(b.amqpURL)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	publishCh, err := conn.Channel()
+	if err != nil {
+		return false, err
+	}
+	consumeCh, err := conn.Channel()
+	if err != nil {
+		return false, err
+	}
+	if err := consumeCh.ExchangeDeclare(b.exchange, "fanout", true, false, false, false, nil); err != nil {
+		return false, err
+	}
+	queue, err := consumeCh.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return false, err
+	}
+	if err := consumeCh.QueueBind(queue.Name, "", b.exchange, false, nil); err != nil {
+		return false, err
+	}
+	deliveries, err := consumeCh.Consume(queue.Name, "", true, true, false, false, nil)
+	if err != nil {
+		return false, err
+	}
+
+	b.setPublishChannel(publishCh)
+	defer b.setPublishChannel(nil)
+	log.Printf("rabbitmq backplane connected exchange=%s", b.exchange)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return true, amqp.ErrClosed
+			}
+			handler(delivery.Body)
+		}
+	}
+}
+
+func (b *Backplane) setPublishChannel(ch *amqp.Channel) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.publishCh = ch
+}
+
+// Close stops consuming and closes the connection.
+func (b *Backplane) Close() error {
+	b.mu.Lock()
+	cancel, done := b.cancel, b.done
+	b.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
+	return nil
+}
@@ -0,0 +1,169 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ErrPermanent marks a consumer failure that retrying cannot fix, such as a malformed event.
+// Such deliveries are dead-lettered at once.
+var ErrPermanent = errors.New("permanent event failure")
+
+// EventHandler processes one consumed event. Errors wrapping ErrPermanent dead-letter the
+// delivery; other errors requeue it once, then dead-letter it.
+type EventHandler interface {
+	HandleEvent(ctx context.Context, routingKey string, body []byte) error
+}
+
+// ConsumerConfig describes where a Consumer reads events from.
+type ConsumerConfig struct {
+	// Exchange is the topic exchange the events are published to.
+	Exchange string
+	// Queue is the durable queue bound to Exchange. Failed deliveries are dead-lettered to
+	// "<Queue>.dlq" through the "<Queue>.dlx" exchange.
+	Queue string
+	// RoutingKeys are the binding keys of Queue.
+	RoutingKeys []string
+	// Prefetch bounds the unacknowledged deliveries held at once.
+	Prefetch int
+	// ReconnectMinBackoff and ReconnectMaxBackoff bound the delay between reconnection
+	// attempts, which doubles after each failure.
+	ReconnectMinBackoff time.Duration
+	ReconnectMaxBackoff time.Duration
+}
+
+// Consumer consumes events from a durable queue and hands them to an EventHandler, one at a
+// time and in delivery order. It declares its queue topology and reconnects when the broker
+// goes away.
+type Consumer struct {
+	amqpURL string
+	cfg     ConsumerConfig
+	handler EventHandler
+}
+
+// NewConsumer constructs a Consumer; nothing is consumed until Run.
+func NewConsumer(amqpURL string, cfg ConsumerConfig, handler EventHandler) *Consumer {
+	return &Consumer{amqpURL: amqpURL, cfg: cfg, handler: handler}
+}
+
+// Run consumes until ctx is cancelled, reconnecting with exponential backoff.
+func (c *Consumer) Run(ctx context.Context) {
+	backoff := c.cfg.ReconnectMinBackoff
+	for {
+		consumed, err := c.consume(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if consumed {
+			backoff = c.cfg.ReconnectMinBackoff
+		}
+		log.Printf("rabbitmq consumer stopped queue=%s, reconnecting in %s: %v", c.cfg.Queue, backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, c.cfg.ReconnectMaxBackoff)
+	}
+}
+
+// consume connects, declares the topology and handles deliveries until the connection drops or
+// ctx is cancelled. It reports whether consuming started.
+func (c *Consumer) consume(ctx context.Context) (bool, error) {
+	conn, err := amqp.Dial(c.amqpURL)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return false, err
+	}
+	if err := c.declare(ch); err != nil {
+		return false, err
+	}
+	if err := ch.Qos(c.cfg.Prefetch, 0, false); err != nil {
+		return false, err
+	}
+	deliveries, err := ch.Consume(c.cfg.Queue, "", false, false, false, false, nil)
+	if err != nil {
+		return false, err
+	}
+	log.Printf("rabbitmq consumer started queue=%s", c.cfg.Queue)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return true, amqp.ErrClosed
+			}
+			c.handle(ctx, delivery)
+		}
+	}
+}
+
+// declare creates the queue, its bindings and its dead-letter queue.
+func (c *Consumer) declare(ch *amqp.Channel) error {
+	dlx, dlq := c.cfg.Queue+".dlx", c.cfg.Queue+".dlq"
+	if err := ch.ExchangeDeclare(dlx, "fanout", true, false, false, false, nil); err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(dlq, true, false, false, false, nil); err != nil {
+		return err
+	}
+	if err := ch.QueueBind(dlq, "", dlx, false, nil); err != nil {
+		return err
+	}
+
+	if err := ch.ExchangeDeclare(c.cfg.Exchange, "topic", true, false, false, false, nil); err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(c.cfg.Queue, true, false, false, false, amqp.Table{"x-dead-letter-exchange": dlx}); err != nil {
+		return err
+	}
+	for _, key := range c.cfg.RoutingKeys {
+		if err := ch.QueueBind(c.cfg.Queue, key, c.cfg.Exchange, false, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handle runs the handler and settles the delivery: acked on success, dead-lettered on
+// permanent failures and on a second failure, requeued otherwise.
+func (c *Consumer) handle(ctx context.Context, delivery amqp.Delivery) {
+	err := c.handler.HandleEvent(ctx, delivery.RoutingKey, delivery.Body)
+	if err == nil {
+		if ackErr := delivery.Ack(false); ackErr != nil {
+			log.Printf("rabbitmq ack failed queue=%s: %v", c.cfg.Queue, ackErr)
+		}
+		return
+	}
+
+	// A failure caused by shutting down is retried by the next consumer.
+	requeue := !errors.Is(err, ErrPermanent) && (!delivery.Redelivered || ctx.Err() != nil)
+	if requeue {
+		log.Printf("rabbitmq event failed, requeueing queue=%s routing_key=%s: %v", c.cfg.Queue, delivery.RoutingKey, err)
+	} else {
+		log.Printf("rabbitmq event failed, dead-lettering queue=%s routing_key=%s: %v", c.cfg.Queue, delivery.RoutingKey, err)
+	}
+	if nackErr := delivery.Nack(false, requeue); nackErr != nil {
+		log.Printf("rabbitmq nack failed queue=%s: %v", c.cfg.Queue, nackErr)
+	}
+}
+
+// permanentf returns an error wrapping ErrPermanent.
+func permanentf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrPermanent, fmt.Sprintf(format, args...))
+}
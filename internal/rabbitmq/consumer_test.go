@@ -0,0 +1,81 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAcknowledger records how a delivery was settled.
+type fakeAcknowledger struct {
+	acked   bool
+	nacked  bool
+	requeue bool
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.acked = true
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	a.nacked = true
+	a.requeue = requeue
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+type handlerFunc func(ctx context.Context, routingKey string, body []byte) error
+
+func (f handlerFunc) HandleEvent(ctx context.Context, routingKey string, body []byte) error {
+	return f(ctx, routingKey, body)
+}
+
+func settle(ctx context.Context, err error, redelivered bool) *fakeAcknowledger {
+	ack := &fakeAcknowledger{}
+	consumer := NewConsumer("amqp://unused", ConsumerConfig{Queue: "test"}, handlerFunc(func(context.Context, string, []byte) error {
+		return err
+	}))
+	consumer.handle(ctx, amqp.Delivery{Acknowledger: ack, RoutingKey: "user.user.deleted", Redelivered: redelivered})
+	return ack
+}
+
+func TestConsumerAcksHandledEvents(t *testing.T) {
+	ack := settle(context.Background(), nil, false)
+
+	assert.True(t, ack.acked)
+	assert.False(t, ack.nacked)
+}
+
+func TestConsumerRequeuesTransientFailureOnce(t *testing.T) {
+	first := settle(context.Background(), errors.New("db down"), false)
+	assert.True(t, first.nacked)
+	assert.True(t, first.requeue)
+
+	second := settle(context.Background(), errors.New("db down"), true)
+	assert.True(t, second.nacked)
+	assert.False(t, second.requeue, "a second failure goes to the dead-letter queue")
+}
+
+func TestConsumerDeadLettersPermanentFailure(t *testing.T) {
+	ack := settle(context.Background(), permanentf("bad payload"), false)
+
+	assert.True(t, ack.nacked)
+	assert.False(t, ack.requeue)
+}
+
+func TestConsumerRequeuesOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ack := settle(ctx, context.Canceled, true)
+
+	assert.True(t, ack.nacked)
+	assert.True(t, ack.requeue)
+}
@@ -3,87 +3,378 @@ package rabbitmq
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 
 	"chat-service/internal/telemetry"
 )
 
-// Publisher publishes audit events.
+// Publisher publishes audit and domain events.
 type Publisher interface {
 	Publish(ctx context.Context, routingKey string, event any) error
 	Close() error
 }
 
-// NewPublisher builds a RabbitMQ publisher or a noop publisher when AMQP is disabled.
+var (
+	// ErrNotConnected is returned by Publish while the connection to the broker is down.
+	ErrNotConnected = errors.New("rabbitmq not connected")
+	// ErrPublisherClosed is returned by Publish after Close.
+	ErrPublisherClosed = errors.New("rabbitmq publisher closed")
+	// ErrNacked is returned when the broker refuses responsibility for a message.
+	ErrNacked = errors.New("rabbitmq nacked the message")
+	// ErrConfirmTimeout is returned when the broker does not confirm a message in time.
+	ErrConfirmTimeout = errors.New("rabbitmq confirm timed out")
+)
+
+// PublisherConfig tunes the AMQP publisher.
+type PublisherConfig struct {
+	// ConfirmTimeout bounds the wait for the broker to confirm a message.
+	ConfirmTimeout time.Duration
+	// PoolSize caps the idle channels kept for reuse. Concurrent publishes beyond it open
+	// short-lived channels.
+	PoolSize int
+	// ReconnectMinBackoff and ReconnectMaxBackoff bound the delay between reconnection
+	// attempts, which doubles after each failure.
+	ReconnectMinBackoff time.Duration
+	ReconnectMaxBackoff time.Duration
+}
+
+// DefaultPublisherConfig returns the publisher settings used in production.
+func DefaultPublisherConfig() PublisherConfig {
+	return PublisherConfig{
+		ConfirmTimeout:      5 * time.Second,
+		PoolSize:            8,
+		ReconnectMinBackoff: 500 * time.Millisecond,
+		ReconnectMaxBackoff: 30 * time.Second,
+	}
+}
+
+// NewPublisher builds a RabbitMQ publisher, or a noop publisher when AMQP is disabled. The
+// publisher keeps reconnecting in the background when the broker is unreachable, at startup
+// or later; Publish fails with ErrNotConnected meanwhile.
 func NewPublisher(amqpURL, exchange string) Publisher {
 	if amqpURL == "" {
 		log.Printf("rabbitmq disabled, using noop: empty amqp url")
 		return noopPublisher{reason: "empty amqp url"}
 	}
+	return newAMQPPublisher(amqpURL, exchange, dialAMQP, DefaultPublisherConfig())
+}
+
+// brokerConnection is the part of *amqp.Connection used by the publisher, so tests can fake the broker.
+type brokerConnection interface {
+	Channel() (brokerChannel, error)
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	Close() error
+}
 
-	conn, err := amqp.Dial(amqpURL)
+// brokerChannel is the part of *amqp.Channel used by the publisher.
+type brokerChannel interface {
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+type dialer func(url string) (brokerConnection, error)
+
+type amqpConnection struct {
+	*amqp.Connection
+}
+
+func (c amqpConnection) Channel() (brokerChannel, error) {
+	ch, err := c.Connection.Channel()
 	if err != nil {
-		log.Printf("rabbitmq disabled, using noop: %v", err)
-		return noopPublisher{reason: err.Error()}
+		return nil, err
 	}
+	return ch, nil
+}
+
+func dialAMQP(url string) (brokerConnection, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	return amqpConnection{conn}, nil
+}
+
+// pooledChannel is a channel in confirm mode. A channel is used by one publish at a time, so
+// the next confirmation received is always the one for that publish.
+type pooledChannel struct {
+	ch       brokerChannel
+	confirms chan amqp.Confirmation
+	// conn is the connection generation the channel was opened on.
+	conn uint64
+}
 
+func openChannel(conn brokerConnection) (*pooledChannel, error) {
 	ch, err := conn.Channel()
 	if err != nil {
-		log.Printf("rabbitmq disabled, using noop: %v", err)
-		_ = conn.Close()
-		return noopPublisher{reason: err.Error()}
-	}
-
-	if err := ch.ExchangeDeclare(
-		exchange,
-		"topic",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	); err != nil {
-		log.Printf("rabbitmq disabled, using noop: %v", err)
+		return nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
 		_ = ch.Close()
-		_ = conn.Close()
-		return noopPublisher{reason: err.Error()}
+		return nil, err
 	}
-
-	log.Printf("rabbitmq connected exchange=%s", exchange)
-	return &amqpPublisher{conn: conn, ch: ch, exchange: exchange}
+	return &pooledChannel{ch: ch, confirms: ch.NotifyPublish(make(chan amqp.Confirmation, 1))}, nil
 }
 
+// amqpPublisher publishes with confirms over a connection it re-establishes whenever the broker
+// closes it.
 type amqpPublisher struct {
-	conn     *amqp.Connection
-	ch       *amqp.Channel
+	url      string
 	exchange string
+	dial     dialer
+	cfg      PublisherConfig
+
+	mu      sync.Mutex
+	conn    brokerConnection // nil while disconnected
+	gen     uint64
+	idle    []*pooledChannel
+	lastErr error
+	closed  bool
+	done    chan struct{}
+}
+
+func newAMQPPublisher(url, exchange string, dial dialer, cfg PublisherConfig) *amqpPublisher {
+	p := &amqpPublisher{url: url, exchange: exchange, dial: dial, cfg: cfg, done: make(chan struct{})}
+	closed, err := p.connect()
+	if err != nil {
+		log.Printf("rabbitmq connect failed, retrying in background exchange=%s: %v", exchange, err)
+	}
+	go p.maintain(closed)
+	return p
+}
+
+// connect dials the broker, declares the exchange and makes the connection current. It returns
+// the channel notified when the connection closes.
+func (p *amqpPublisher) connect() (chan *amqp.Error, error) {
+	conn, err := p.dial(p.url)
+	if err != nil {
+		p.setLastErr(err)
+		return nil, err
+	}
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	pc, err := openChannel(conn)
+	if err == nil {
+		err = pc.ch.ExchangeDeclare(p.exchange, "topic", true, false, false, false, nil)
+	}
+	if err != nil {
+		_ = conn.Close()
+		p.setLastErr(err)
+		return nil, err
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		_ = conn.Close()
+		return nil, ErrPublisherClosed
+	}
+	p.gen++
+	p.conn = conn
+	pc.conn = p.gen
+	p.idle = append(p.idle, pc)
+	p.lastErr = nil
+	p.mu.Unlock()
+
+	log.Printf("rabbitmq connected exchange=%s", p.exchange)
+	return closed, nil
 }
 
+// maintain waits for the connection to close and reconnects with exponential backoff, until
+// the publisher is closed.
+func (p *amqpPublisher) maintain(closed chan *amqp.Error) {
+	backoff := p.cfg.ReconnectMinBackoff
+	for {
+		if closed == nil {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-p.done:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			var err error
+			if closed, err = p.connect(); err != nil {
+				if errors.Is(err, ErrPublisherClosed) {
+					return
+				}
+				log.Printf("rabbitmq reconnect failed exchange=%s: %v", p.exchange, err)
+				backoff = min(backoff*2, p.cfg.ReconnectMaxBackoff)
+				continue
+			}
+			backoff = p.cfg.ReconnectMinBackoff
+		}
+
+		select {
+		case <-p.done:
+			return
+		case amqpErr := <-closed:
+			p.disconnected(amqpErr)
+			closed = nil
+		}
+	}
+}
+
+// disconnected drops the closed connection and its pooled channels.
+func (p *amqpPublisher) disconnected(amqpErr *amqp.Error) {
+	var cause error = amqp.ErrClosed
+	if amqpErr != nil {
+		cause = amqpErr
+	}
+
+	p.mu.Lock()
+	p.conn = nil
+	idle := p.idle
+	p.idle = nil
+	p.lastErr = cause
+	p.mu.Unlock()
+
+	for _, pc := range idle {
+		_ = pc.ch.Close()
+	}
+	log.Printf("rabbitmq connection lost exchange=%s: %v", p.exchange, cause)
+}
+
+func (p *amqpPublisher) setLastErr(err error) {
+	p.mu.Lock()
+	p.lastErr = err
+	p.mu.Unlock()
+}
+
+// Publish sends event as JSON and waits for the broker to confirm it.
 func (p *amqpPublisher) Publish(ctx context.Context, routingKey string, event any) error {
 	body, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
 
-	err = p.ch.PublishWithContext(ctx, p.exchange, routingKey, false, false, amqp.Publishing{
+	pc, err := p.acquire()
+	if err != nil {
+		return err
+	}
+	if err := p.publish(ctx, pc, routingKey, body); err != nil {
+		// The channel may still owe a confirmation for this message; never reuse it.
+		_ = pc.ch.Close()
+		log.Printf("rabbitmq publish failed: %v", err)
+		return err
+	}
+	p.release(pc)
+	return nil
+}
+
+func (p *amqpPublisher) publish(ctx context.Context, pc *pooledChannel, routingKey string, body []byte) error {
+	if err := pc.ch.PublishWithContext(ctx, p.exchange, routingKey, false, false, amqp.Publishing{
 		ContentType:  "application/json",
 		DeliveryMode: amqp.Persistent,
 		Body:         body,
-	})
+	}); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(p.cfg.ConfirmTimeout)
+	defer timer.Stop()
+	select {
+	case confirm, ok := <-pc.confirms:
+		if !ok {
+			return amqp.ErrClosed
+		}
+		if !confirm.Ack {
+			return ErrNacked
+		}
+		return nil
+	case <-timer.C:
+		return ErrConfirmTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// acquire takes an idle channel from the pool or opens a new one on the current connection.
+func (p *amqpPublisher) acquire() (*pooledChannel, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPublisherClosed
+	}
+	if p.conn == nil {
+		err := ErrNotConnected
+		if p.lastErr != nil {
+			err = fmt.Errorf("%w: %v", ErrNotConnected, p.lastErr)
+		}
+		p.mu.Unlock()
+		return nil, err
+	}
+	if n := len(p.idle); n > 0 {
+		pc := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return pc, nil
+	}
+	conn, gen := p.conn, p.gen
+	p.mu.Unlock()
+
+	pc, err := openChannel(conn)
 	if err != nil {
-		log.Printf("rabbitmq publish failed: %v", err)
+		return nil, err
+	}
+	pc.conn = gen
+	return pc, nil
+}
+
+// release returns a channel to the pool, or closes it when the pool is full or the channel
+// belongs to a previous connection.
+func (p *amqpPublisher) release(pc *pooledChannel) {
+	p.mu.Lock()
+	if !p.closed && p.conn != nil && pc.conn == p.gen && len(p.idle) < p.cfg.PoolSize {
+		p.idle = append(p.idle, pc)
+		p.mu.Unlock()
+		return
 	}
-	return err
+	p.mu.Unlock()
+	_ = pc.ch.Close()
 }
 
+// mode reports whether the publisher is currently connected.
+func (p *amqpPublisher) mode() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case p.closed:
+		return "closed"
+	case p.conn == nil:
+		return "reconnecting"
+	default:
+		return "amqp"
+	}
+}
+
+// Close stops reconnecting and closes the connection.
 func (p *amqpPublisher) Close() error {
-	if p.ch != nil {
-		_ = p.ch.Close()
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	conn, idle := p.conn, p.idle
+	p.conn, p.idle = nil, nil
+	p.mu.Unlock()
+
+	for _, pc := range idle {
+		_ = pc.ch.Close()
 	}
-	if p.conn != nil {
-		return p.conn.Close()
+	if conn != nil {
+		return conn.Close()
 	}
 	return nil
 }
@@ -98,6 +389,8 @@ func (noopPublisher) Publish(ctx context.Context, routingKey string, event any)
 		log.Printf("rabbitmq noop publish routing_key=%s event_type=%s service=%s request_id=%s", routingKey, envelope.EventType, envelope.Service, envelope.RequestID)
 	case *telemetry.Envelope:
 		log.Printf("rabbitmq noop publish routing_key=%s event_type=%s service=%s request_id=%s", routingKey, envelope.EventType, envelope.Service, envelope.RequestID)
+	case telemetry.EventEnvelope:
+		log.Printf("rabbitmq noop publish routing_key=%s event_type=%s service=%s event_id=%s", routingKey, envelope.EventType, envelope.Service, envelope.EventID)
 	default:
 		log.Printf("rabbitmq noop publish routing_key=%s", routingKey)
 	}
@@ -108,11 +401,12 @@ func (noopPublisher) Close() error {
 	return nil
 }
 
-// PublisherMode reports the publisher mode for logging.
+// PublisherMode reports the live publisher state for logging: "amqp" while connected,
+// "reconnecting" while the broker is unreachable, or "noop".
 func PublisherMode(p Publisher) string {
-	switch p.(type) {
+	switch publisher := p.(type) {
 	case *amqpPublisher:
-		return "amqp"
+		return publisher.mode()
 	case noopPublisher:
 		return "noop"
 	case *noopPublisher:
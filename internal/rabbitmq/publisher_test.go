@@ -0,0 +1,260 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroker stands in for RabbitMQ: it hands out fake connections and confirms, nacks or
+// ignores every publish.
+type fakeBroker struct {
+	mu        sync.Mutex
+	dialErr   error
+	nack      bool
+	noConfirm bool
+	conns     []*fakeConn
+	published []string
+}
+
+func (b *fakeBroker) dial(url string) (brokerConnection, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.dialErr != nil {
+		return nil, b.dialErr
+	}
+	conn := &fakeConn{broker: b}
+	b.conns = append(b.conns, conn)
+	return conn, nil
+}
+
+func (b *fakeBroker) set(fn func(b *fakeBroker)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fn(b)
+}
+
+func (b *fakeBroker) conn(i int) *fakeConn {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.conns[i]
+}
+
+func (b *fakeBroker) connCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.conns)
+}
+
+func (b *fakeBroker) publishedKeys() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.published...)
+}
+
+type fakeConn struct {
+	broker   *fakeBroker
+	mu       sync.Mutex
+	closeCh  chan *amqp.Error
+	channels int
+	closed   bool
+}
+
+func (c *fakeConn) Channel() (brokerChannel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, amqp.ErrClosed
+	}
+	c.channels++
+	return &fakeChannel{broker: c.broker}, nil
+}
+
+func (c *fakeConn) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeCh = receiver
+	return receiver
+}
+
+func (c *fakeConn) Close() error {
+	c.drop(nil)
+	return nil
+}
+
+// drop closes the connection as the broker would, reporting err to NotifyClose listeners.
+func (c *fakeConn) drop(err *amqp.Error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	if err != nil {
+		c.closeCh <- err
+	}
+	close(c.closeCh)
+}
+
+func (c *fakeConn) channelCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.channels
+}
+
+type fakeChannel struct {
+	broker   *fakeBroker
+	mu       sync.Mutex
+	confirms chan amqp.Confirmation
+	tag      uint64
+	closed   bool
+}
+
+func (ch *fakeChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	return nil
+}
+
+func (ch *fakeChannel) Confirm(noWait bool) error { return nil }
+
+func (ch *fakeChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	ch.confirms = confirm
+	return confirm
+}
+
+func (ch *fakeChannel) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.closed {
+		return amqp.ErrClosed
+	}
+
+	ch.broker.mu.Lock()
+	ch.broker.published = append(ch.broker.published, key)
+	nack, noConfirm := ch.broker.nack, ch.broker.noConfirm
+	ch.broker.mu.Unlock()
+
+	ch.tag++
+	if !noConfirm {
+		ch.confirms <- amqp.Confirmation{DeliveryTag: ch.tag, Ack: !nack}
+	}
+	return nil
+}
+
+func (ch *fakeChannel) Close() error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if !ch.closed {
+		ch.closed = true
+		close(ch.confirms)
+	}
+	return nil
+}
+
+func newTestPublisher(t *testing.T, broker *fakeBroker) *amqpPublisher {
+	t.Helper()
+	p := newAMQPPublisher("amqp://test", "chat.domain.events", broker.dial, PublisherConfig{
+		ConfirmTimeout:      50 * time.Millisecond,
+		PoolSize:            4,
+		ReconnectMinBackoff: time.Millisecond,
+		ReconnectMaxBackoff: 5 * time.Millisecond,
+	})
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestPublisherConfirmsAndReusesChannel(t *testing.T) {
+	broker := &fakeBroker{}
+	p := newTestPublisher(t, broker)
+
+	require.Equal(t, "amqp", PublisherMode(p))
+	require.NoError(t, p.Publish(context.Background(), "chat-service.message.created", map[string]int{"id": 1}))
+	require.NoError(t, p.Publish(context.Background(), "chat-service.message.edited", map[string]int{"id": 1}))
+
+	assert.Equal(t, []string{"chat-service.message.created", "chat-service.message.edited"}, broker.publishedKeys())
+	assert.Equal(t, 1, broker.conn(0).channelCount())
+}
+
+func TestPublisherNackDiscardsChannel(t *testing.T) {
+	broker := &fakeBroker{nack: true}
+	p := newTestPublisher(t, broker)
+
+	err := p.Publish(context.Background(), "key", "event")
+	require.ErrorIs(t, err, ErrNacked)
+
+	broker.set(func(b *fakeBroker) { b.nack = false })
+	require.NoError(t, p.Publish(context.Background(), "key", "event"))
+	assert.Equal(t, 2, broker.conn(0).channelCount())
+}
+
+func TestPublisherConfirmTimeout(t *testing.T) {
+	broker := &fakeBroker{noConfirm: true}
+	p := newTestPublisher(t, broker)
+
+	err := p.Publish(context.Background(), "key", "event")
+	require.ErrorIs(t, err, ErrConfirmTimeout)
+}
+
+func TestPublisherConnectsAfterStartupFailure(t *testing.T) {
+	broker := &fakeBroker{dialErr: errors.New("connection refused")}
+	p := newTestPublisher(t, broker)
+
+	require.Equal(t, "reconnecting", PublisherMode(p))
+	err := p.Publish(context.Background(), "key", "event")
+	require.ErrorIs(t, err, ErrNotConnected)
+
+	broker.set(func(b *fakeBroker) { b.dialErr = nil })
+	require.Eventually(t, func() bool { return PublisherMode(p) == "amqp" }, time.Second, time.Millisecond)
+	require.NoError(t, p.Publish(context.Background(), "key", "event"))
+}
+
+func TestPublisherReconnectsAfterConnectionLoss(t *testing.T) {
+	broker := &fakeBroker{}
+	p := newTestPublisher(t, broker)
+	require.NoError(t, p.Publish(context.Background(), "key", "before"))
+
+	broker.conn(0).drop(&amqp.Error{Code: amqp.ConnectionForced, Reason: "CONNECTION_FORCED"})
+	require.Eventually(t, func() bool { return broker.connCount() == 2 && PublisherMode(p) == "amqp" }, time.Second, time.Millisecond)
+
+	require.NoError(t, p.Publish(context.Background(), "key", "after"))
+	assert.Equal(t, 1, broker.conn(1).channelCount())
+}
+
+func TestPublisherConcurrentPublishes(t *testing.T) {
+	broker := &fakeBroker{}
+	p := newTestPublisher(t, broker)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- p.Publish(context.Background(), "key", "event")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	assert.Len(t, broker.publishedKeys(), 20)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	assert.LessOrEqual(t, len(p.idle), 4)
+}
+
+func TestPublisherClose(t *testing.T) {
+	broker := &fakeBroker{}
+	p := newTestPublisher(t, broker)
+
+	require.NoError(t, p.Close())
+	assert.Equal(t, "closed", PublisherMode(p))
+	require.ErrorIs(t, p.Publish(context.Background(), "key", "event"), ErrPublisherClosed)
+}
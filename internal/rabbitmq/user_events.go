@@ -0,0 +1,149 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"chat-service/internal/models"
+	"chat-service/internal/repositories"
+)
+
+// User-service event types consumed by chat-service.
+const (
+	UserEventFriendshipRemoved = "friendship.removed"
+	UserEventUserBlocked       = "user.blocked"
+	UserEventUserDeleted       = "user.deleted"
+)
+
+// UserEventRoutingKeys are the binding keys matching the consumed user-service events, with or
+// without a service prefix.
+var UserEventRoutingKeys = []string{
+	"#." + UserEventFriendshipRemoved,
+	"#." + UserEventUserBlocked,
+	"#." + UserEventUserDeleted,
+}
+
+// userEventEnvelope is the part of a user-service event envelope chat-service reads.
+type userEventEnvelope struct {
+	EventID   string          `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type friendshipRemovedPayload struct {
+	UserID   int `json:"user_id"`
+	FriendID int `json:"friend_id"`
+}
+
+type userBlockedPayload struct {
+	BlockerID int `json:"blocker_id"`
+	BlockedID int `json:"blocked_id"`
+}
+
+type userDeletedPayload struct {
+	UserID int `json:"user_id"`
+}
+
+// userEventNotifier pushes the effects of user-service events to connected clients.
+type userEventNotifier interface {
+	BroadcastChatReadOnly(chatID int, reason string)
+	DisconnectGroupUser(groupID int, userID int)
+}
+
+// UserEventHandler applies user-service events: unfriending and blocking make the users'
+// private chat read-only, and deleting an account removes the user's data.
+type UserEventHandler struct {
+	repo repositories.UserEventRepository
+	hub  userEventNotifier
+}
+
+// NewUserEventHandler constructs a UserEventHandler.
+func NewUserEventHandler(repo repositories.UserEventRepository, hub userEventNotifier) *UserEventHandler {
+	return &UserEventHandler{repo: repo, hub: hub}
+}
+
+// HandleEvent implements EventHandler. Events are dispatched on their event_type; unknown types
+// are ignored and redelivered events are skipped.
+func (h *UserEventHandler) HandleEvent(ctx context.Context, routingKey string, body []byte) error {
+	var envelope userEventEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return permanentf("decode event: %v", err)
+	}
+	if envelope.EventID == "" {
+		return permanentf("event without event_id")
+	}
+
+	var err error
+	switch envelope.EventType {
+	case UserEventFriendshipRemoved:
+		var payload friendshipRemovedPayload
+		if err := decodeUserPayload(envelope, &payload); err != nil {
+			return err
+		}
+		if payload.UserID <= 0 || payload.FriendID <= 0 {
+			return permanentf("%s: user_id and friend_id are required", envelope.EventType)
+		}
+		err = h.restrictChat(ctx, envelope, payload.UserID, payload.FriendID, models.ChatReadOnlyUnfriended)
+	case UserEventUserBlocked:
+		var payload userBlockedPayload
+		if err := decodeUserPayload(envelope, &payload); err != nil {
+			return err
+		}
+		if payload.BlockerID <= 0 || payload.BlockedID <= 0 {
+			return permanentf("%s: blocker_id and blocked_id are required", envelope.EventType)
+		}
+		err = h.restrictChat(ctx, envelope, payload.BlockerID, payload.BlockedID, models.ChatReadOnlyBlocked)
+	case UserEventUserDeleted:
+		var payload userDeletedPayload
+		if err := decodeUserPayload(envelope, &payload); err != nil {
+			return err
+		}
+		if payload.UserID <= 0 {
+			return permanentf("%s: user_id is required", envelope.EventType)
+		}
+		err = h.deleteUser(ctx, envelope, payload.UserID)
+	default:
+		log.Printf("user event ignored routing_key=%s event_type=%s", routingKey, envelope.EventType)
+		return nil
+	}
+
+	if errors.Is(err, repositories.ErrEventProcessed) {
+		log.Printf("user event already processed event_id=%s event_type=%s", envelope.EventID, envelope.EventType)
+		return nil
+	}
+	return err
+}
+
+func (h *UserEventHandler) restrictChat(ctx context.Context, envelope userEventEnvelope, userID, otherID int, reason string) error {
+	chatID, err := h.repo.RestrictChat(ctx, envelope.EventID, envelope.EventType, userID, otherID, reason)
+	if err != nil {
+		return err
+	}
+	if chatID != 0 {
+		h.hub.BroadcastChatReadOnly(chatID, reason)
+	}
+	return nil
+}
+
+func (h *UserEventHandler) deleteUser(ctx context.Context, envelope userEventEnvelope, userID int) error {
+	deletion, err := h.repo.DeleteUserData(ctx, envelope.EventID, envelope.EventType, userID)
+	if err != nil {
+		return err
+	}
+	for _, chatID := range deletion.ChatIDs {
+		h.hub.BroadcastChatReadOnly(chatID, models.ChatReadOnlyAccountDeleted)
+	}
+	for _, groupID := range deletion.GroupIDs {
+		h.hub.DisconnectGroupUser(groupID, userID)
+	}
+	return nil
+}
+
+func decodeUserPayload(envelope userEventEnvelope, payload any) error {
+	if err := json.Unmarshal(envelope.Payload, payload); err != nil {
+		return permanentf("decode %s payload: %v", envelope.EventType, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"chat-service/internal/mocks"
+	"chat-service/internal/models"
+	"chat-service/internal/repositories"
+)
+
+type recordingNotifier struct {
+	readOnly     map[int]string
+	disconnected [][2]int
+}
+
+func (n *recordingNotifier) BroadcastChatReadOnly(chatID int, reason string) {
+	if n.readOnly == nil {
+		n.readOnly = map[int]string{}
+	}
+	n.readOnly[chatID] = reason
+}
+
+func (n *recordingNotifier) DisconnectGroupUser(groupID int, userID int) {
+	n.disconnected = append(n.disconnected, [2]int{groupID, userID})
+}
+
+func TestUserEventHandlerFriendshipRemoved(t *testing.T) {
+	repo := new(mocks.UserEventRepositoryMock)
+	notifier := &recordingNotifier{}
+	repo.On("RestrictChat", mock.Anything, "evt-1", UserEventFriendshipRemoved, 1, 2, models.ChatReadOnlyUnfriended).Return(10, nil)
+
+	handler := NewUserEventHandler(repo, notifier)
+	err := handler.HandleEvent(context.Background(), "user.friendship.removed",
+		[]byte(`{"event_id":"evt-1","event_type":"friendship.removed","payload":{"user_id":1,"friend_id":2}}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]string{10: models.ChatReadOnlyUnfriended}, notifier.readOnly)
+	repo.AssertExpectations(t)
+}
+
+func TestUserEventHandlerUserBlockedWithoutChat(t *testing.T) {
+	repo := new(mocks.UserEventRepositoryMock)
+	notifier := &recordingNotifier{}
+	repo.On("RestrictChat", mock.Anything, "evt-2", UserEventUserBlocked, 3, 4, models.ChatReadOnlyBlocked).Return(0, nil)
+
+	handler := NewUserEventHandler(repo, notifier)
+	err := handler.HandleEvent(context.Background(), "user.user.blocked",
+		[]byte(`{"event_id":"evt-2","event_type":"user.blocked","payload":{"blocker_id":3,"blocked_id":4}}`))
+
+	assert.NoError(t, err)
+	assert.Empty(t, notifier.readOnly)
+	repo.AssertExpectations(t)
+}
+
+func TestUserEventHandlerUserDeleted(t *testing.T) {
+	repo := new(mocks.UserEventRepositoryMock)
+	notifier := &recordingNotifier{}
+	repo.On("DeleteUserData", mock.Anything, "evt-3", UserEventUserDeleted, 5).
+		Return(repositories.UserDeletion{ChatIDs: []int{7}, GroupIDs: []int{8, 9}}, nil)
+
+	handler := NewUserEventHandler(repo, notifier)
+	err := handler.HandleEvent(context.Background(), "user.user.deleted",
+		[]byte(`{"event_id":"evt-3","event_type":"user.deleted","payload":{"user_id":5}}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]string{7: models.ChatReadOnlyAccountDeleted}, notifier.readOnly)
+	assert.Equal(t, [][2]int{{8, 5}, {9, 5}}, notifier.disconnected)
+	repo.AssertExpectations(t)
+}
+
+func TestUserEventHandlerSkipsProcessedEvents(t *testing.T) {
+	repo := new(mocks.UserEventRepositoryMock)
+	notifier := &recordingNotifier{}
+	repo.On("DeleteUserData", mock.Anything, "evt-3", UserEventUserDeleted, 5).
+		Return(repositories.UserDeletion{}, repositories.ErrEventProcessed)
+
+	handler := NewUserEventHandler(repo, notifier)
+	err := handler.HandleEvent(context.Background(), "user.user.deleted",
+		[]byte(`{"event_id":"evt-3","event_type":"user.deleted","payload":{"user_id":5}}`))
+
+	assert.NoError(t, err)
+	assert.Empty(t, notifier.disconnected)
+}
+
+func TestUserEventHandlerRejectsMalformedEvents(t *testing.T) {
+	handler := NewUserEventHandler(new(mocks.UserEventRepositoryMock), &recordingNotifier{})
+
+	for _, body := range []string{
+		`not json`,
+		`{"event_type":"user.deleted","payload":{"user_id":5}}`,
+		`{"event_id":"evt-4","event_type":"user.deleted","payload":{}}`,
+		`{"event_id":"evt-5","event_type":"friendship.removed","payload":"oops"}`,
+	} {
+		err := handler.HandleEvent(context.Background(), "user.user.deleted", []byte(body))
+		assert.True(t, errors.Is(err, ErrPermanent), body)
+	}
+}
+
+func TestUserEventHandlerIgnoresUnknownEvents(t *testing.T) {
+	handler := NewUserEventHandler(new(mocks.UserEventRepositoryMock), &recordingNotifier{})
+
+	err := handler.HandleEvent(context.Background(), "user.user.renamed",
+		[]byte(`{"event_id":"evt-6","event_type":"user.renamed","payload":{"user_id":5}}`))
+
+	assert.NoError(t, err)
+}
+
+func TestUserEventHandlerReturnsStoreErrors(t *testing.T) {
+	repo := new(mocks.UserEventRepositoryMock)
+	repo.On("RestrictChat", mock.Anything, "evt-7", UserEventFriendshipRemoved, 1, 2, models.ChatReadOnlyUnfriended).Return(0, errors.New("db down"))
+
+	handler := NewUserEventHandler(repo, &recordingNotifier{})
+	err := handler.HandleEvent(context.Background(), "user.friendship.removed",
+		[]byte(`{"event_id":"evt-7","event_type":"friendship.removed","payload":{"user_id":1,"friend_id":2}}`))
+
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrPermanent))
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"chat-service/internal/models"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrAttachmentUnavailable is returned when a message references an attachment that does not
+	// exist, was uploaded by someone else or already belongs to another message.
+	ErrAttachmentUnavailable = errors.New("attachment unavailable")
+)
+
+const attachmentColumns = `id, uploader_id, storage_key, file_name, content_type, size_bytes, scope, message_id, created_at`
+
+// AttachmentRepository stores attachment metadata. Contents are kept in blob storage.
+type AttachmentRepository interface {
+	CreateAttachment(ctx context.Context, attachment models.Attachment) (models.Attachment, error)
+	GetAttachment(ctx context.Context, attachmentID int) (models.Attachment, error)
+}
+
+// AttachmentRepo is a sqlx-backed repository.
+type AttachmentRepo struct {
+	db *sqlx.DB
+}
+
+// NewAttachmentRepo constructs AttachmentRepo.
+func NewAttachmentRepo(db *sqlx.DB) *AttachmentRepo {
+	return &AttachmentRepo{db: db}
+}
+
+// CreateAttachment records an uploaded file that is not yet linked to a message.
+func (r *AttachmentRepo) CreateAttachment(ctx context.Context, attachment models.Attachment) (models.Attachment, error) {
+	var created models.Attachment
+	err := r.db.QueryRowxContext(ctx, `INSERT INTO attachments (uploader_id, storage_key, file_name, content_type, size_bytes)
+        VALUES ($1, $2, $3, $4, $5) RETURNING `+attachmentColumns,
+		attachment.UploaderID, attachment.StorageKey, attachment.FileName, attachment.ContentType, attachment.Size).
+		StructScan(&created)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	created.URL = models.AttachmentURL(created.ID)
+	return created, nil
+}
+
+// GetAttachment retrieves a single attachment.
+func (r *AttachmentRepo) GetAttachment(ctx context.Context, attachmentID int) (models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.GetContext(ctx, &attachment, `SELECT `+attachmentColumns+` FROM attachments WHERE id=$1`, attachmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Attachment{}, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	attachment.URL = models.AttachmentURL(attachment.ID)
+	return attachment, nil
+}
+
+// claimAttachments links unclaimed attachments uploaded by uploaderID to a new message.
+// It fails with ErrAttachmentUnavailable unless every id could be claimed.
+func claimAttachments(ctx context.Context, tx *sqlx.Tx, scope string, messageID, uploaderID int, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE attachments SET scope=$1, message_id=$2
+        WHERE id = ANY($3) AND uploader_id=$4 AND message_id IS NULL`, scope, messageID, pq.Array(ids), uploaderID)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count != int64(len(ids)) {
+		return ErrAttachmentUnavailable
+	}
+	return nil
+}
+
+// loadAttachments fetches the attachments of the given messages in upload order.
+func loadAttachments(ctx context.Context, q sqlx.QueryerContext, scope string, messageIDs []int) (map[int][]models.Attachment, error) {
+	result := map[int][]models.Attachment{}
+	if len(messageIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []models.Attachment
+	if err := sqlx.SelectContext(ctx, q, &rows, `SELECT `+attachmentColumns+` FROM attachments
+        WHERE scope=$1 AND message_id = ANY($2) ORDER BY id`, scope, pq.Array(messageIDs)); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		row.URL = models.AttachmentURL(row.ID)
+		result[*row.MessageID] = append(result[*row.MessageID], row)
+	}
+	return result, nil
+}
+
+func attachChatAttachments(ctx context.Context, q sqlx.QueryerContext, msgs []models.Message) error {
+	ids := make([]int, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+	attachments, err := loadAttachments(ctx, q, models.AttachmentScopeChat, ids)
+	if err != nil {
+		return err
+	}
+	for i := range msgs {
+		msgs[i].Attachments = attachments[msgs[i].ID]
+	}
+	return nil
+}
+
+func attachGroupAttachments(ctx context.Context, q sqlx.QueryerContext, msgs []models.GroupMessage) error {
+	ids := make([]int, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+	attachments, err := loadAttachments(ctx, q, models.AttachmentScopeGroup, ids)
+	if err != nil {
+		return err
+	}
+	for i := range msgs {
+		msgs[i].Attachments = attachments[msgs[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+
+	"chat-service/internal/models"
+)
+
+// BlockRepository persists the users each user has blocked.
+type BlockRepository interface {
+	Block(ctx context.Context, blockerID int, blockedID int) (bool, error)
+	Unblock(ctx context.Context, blockerID int, blockedID int) (bool, error)
+	IsBlocked(ctx context.Context, userID int, otherID int) (bool, error)
+	BlockedUsers(ctx context.Context, userID int) ([]int, error)
+}
+
+// notBlockedSender filters group messages aliased alias to those the user bound to $viewerArg may
+// see: system messages, and messages of users they did not block.
+func notBlockedSender(alias string, viewerArg int) string {
+	return `(` + alias + `.kind = '` + models.GroupMessageKindSystem + `' OR NOT EXISTS (SELECT 1 FROM user_blocks ub
+            WHERE ub.blocker_id = $` + strconv.Itoa(viewerArg) + ` AND ub.blocked_id = ` + alias + `.sender_id))`
+}
+
+// BlockRepo is a sqlx implementation of BlockRepository.
+type BlockRepo struct {
+	db *sqlx.DB
+}
+
+// NewBlockRepo constructs a BlockRepo.
+func NewBlockRepo(db *sqlx.DB) *BlockRepo {
+	return &BlockRepo{db: db}
+}
+
+// Block records that blockerID blocked blockedID. It reports false when the block already existed.
+func (r *BlockRepo) Block(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2)
+        ON CONFLICT (blocker_id, blocked_id) DO NOTHING`, blockerID, blockedID)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	return count > 0, err
+}
+
+// Unblock removes a block. It reports false when there was none.
+func (r *BlockRepo) Unblock(ctx context.Context, blockerID int, blockedID int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM user_blocks WHERE blocker_id=$1 AND blocked_id=$2`, blockerID, blockedID)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	return count > 0, err
+}
+
+// IsBlocked reports whether either user blocked the other.
+func (r *BlockRepo) IsBlocked(ctx context.Context, userID int, otherID int) (bool, error) {
+	var blocked bool
+	err := r.db.GetContext(ctx, &blocked, `SELECT EXISTS (SELECT 1 FROM user_blocks
+        WHERE (blocker_id=$1 AND blocked_id=$2) OR (blocker_id=$2 AND blocked_id=$1))`, userID, otherID)
+	return blocked, err
+}
+
+// BlockedUsers returns the ids of the users userID blocked.
+func (r *BlockRepo) BlockedUsers(ctx context.Context, userID int) ([]int, error) {
+	blockedIDs := []int{}
+	err := r.db.SelectContext(ctx, &blockedIDs, `SELECT blocked_id FROM user_blocks WHERE blocker_id=$1 ORDER BY blocked_id`, userID)
+	return blockedIDs, err
+}
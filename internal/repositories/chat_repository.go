@@ -5,14 +5,20 @@ import (
 	"database/sql"
 	"errors"
 	"sort"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
+	"chat-service/internal/events"
 	"chat-service/internal/models"
+	"chat-service/internal/outbox"
 )
 
 var ErrChatNotFound = errors.New("chat not found")
 
+// chatColumns lists the chats columns in models.Chat order.
+const chatColumns = `id, user1_id, user2_id, created_at, read_only_reason`
+
 // ChatRepository abstracts chat persistence.
 type ChatRepository interface {
 	CreateOrGetChat(ctx context.Context, userID int, friendID int) (models.Chat, error)
@@ -21,19 +27,23 @@ type ChatRepository interface {
 	ListChats(ctx context.Context, userID int) ([]models.ChatSummary, error)
 	HideChatForUser(ctx context.Context, chatID int, userID int) error
 	UnhideChatForUser(ctx context.Context, chatID int, userID int) error
+	MarkRead(ctx context.Context, chatID int, userID int, messageID int) (int, bool, error)
+	ListChatIDs(ctx context.Context, userID int) ([]int, error)
 }
 
 // ChatRepo is a sqlx implementation of ChatRepository.
 type ChatRepo struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	outbox *outbox.Writer
 }
 
-// NewChatRepo constructs a ChatRepo.
-func NewChatRepo(db *sqlx.DB) *ChatRepo {
-	return &ChatRepo{db: db}
+// NewChatRepo constructs a ChatRepo recording domain events in events.
+func NewChatRepo(db *sqlx.DB, events *outbox.Writer) *ChatRepo {
+	return &ChatRepo{db: db, outbox: events}
 }
 
-// CreateOrGetChat creates a chat between two users if it does not already exist.
+// CreateOrGetChat creates a chat between two users if it does not already exist and makes it
+// visible to both again.
 func (r *ChatRepo) CreateOrGetChat(ctx context.Context, userID int, friendID int) (models.Chat, error) {
 	if userID == friendID {
 		return models.Chat{}, errors.New("cannot create chat with self")
@@ -42,24 +52,41 @@ func (r *ChatRepo) CreateOrGetChat(ctx context.Context, userID int, friendID int
 	sort.Ints(participants)
 	user1, user2 := participants[0], participants[1]
 
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Chat{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Starting the chat again reopens it, unless one of the accounts was deleted.
 	var chat models.Chat
-	query := `SELECT id, user1_id, user2_id, created_at FROM chats WHERE user1_id=$1 AND user2_id=$2`
-	if err := r.db.GetContext(ctx, &chat, query, user1, user2); err != nil {
+	query := `UPDATE chats SET read_only_reason = CASE WHEN read_only_reason = $3 THEN read_only_reason END
+        WHERE user1_id=$1 AND user2_id=$2
+        RETURNING ` + chatColumns
+	if err = tx.GetContext(ctx, &chat, query, user1, user2, models.ChatReadOnlyAccountDeleted); err != nil {
 		if err != sql.ErrNoRows {
 			return models.Chat{}, err
 		}
-		if err := r.db.QueryRowxContext(ctx, `INSERT INTO chats (user1_id, user2_id) VALUES ($1, $2) RETURNING id, user1_id, user2_id, created_at`, user1, user2).
-			Scan(&chat.ID, &chat.User1ID, &chat.User2ID, &chat.CreatedAt); err != nil {
+		if err = tx.GetContext(ctx, &chat, `INSERT INTO chats (user1_id, user2_id) VALUES ($1, $2) RETURNING `+chatColumns, user1, user2); err != nil {
 			return models.Chat{}, err
 		}
-	} else {
-		// ensure participants column order matches request
 	}
 
-	if err := r.UnhideChatForUser(ctx, chat.ID, userID); err != nil {
+	if err = unhideChat(ctx, tx, chat.ID, userID); err != nil {
 		return models.Chat{}, err
 	}
-	if err := r.UnhideChatForUser(ctx, chat.ID, friendID); err != nil {
+	if err = unhideChat(ctx, tx, chat.ID, friendID); err != nil {
+		return models.Chat{}, err
+	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeChat, chat.ID),
+		events.ChatStarted{ChatID: chat.ID, UserIDs: []int{chat.User1ID, chat.User2ID}, InitiatorID: userID}, userID); err != nil {
+		return models.Chat{}, err
+	}
+	if err = tx.Commit(); err != nil {
 		return models.Chat{}, err
 	}
 	return chat, nil
@@ -72,22 +99,53 @@ func (r *ChatRepo) IsParticipant(ctx context.Context, chatID int, userID int) (b
 	return exists, err
 }
 
+// ListChatIDs returns the ids of every chat the user participates in, including hidden ones.
+func (r *ChatRepo) ListChatIDs(ctx context.Context, userID int) ([]int, error) {
+	var ids []int
+	err := r.db.SelectContext(ctx, &ids, `SELECT id FROM chats WHERE user1_id=$1 OR user2_id=$1`, userID)
+	return ids, err
+}
+
 // GetChat fetches a chat by id.
 func (r *ChatRepo) GetChat(ctx context.Context, chatID int) (models.Chat, error) {
 	var chat models.Chat
-	err := r.db.GetContext(ctx, &chat, `SELECT id, user1_id, user2_id, created_at FROM chats WHERE id=$1`, chatID)
+	err := r.db.GetContext(ctx, &chat, `SELECT `+chatColumns+` FROM chats WHERE id=$1`, chatID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return models.Chat{}, ErrChatNotFound
 	}
 	return chat, err
 }
 
-// ListChats returns chats visible to the user.
+// ListChats returns chats visible to the user along with the user's read state and
+// a preview of the latest message the user can see, most recently active first.
 func (r *ChatRepo) ListChats(ctx context.Context, userID int) ([]models.ChatSummary, error) {
-	query := `SELECT c.id, c.user1_id, c.user2_id, c.created_at FROM chats c
+	query := `SELECT c.id, c.user1_id, c.user2_id, c.created_at, c.read_only_reason,
+            COALESCE(cr.last_read_message_id, 0) AS last_read_message_id,
+            (SELECT COUNT(*) FROM messages m
+                WHERE m.chat_id = c.id
+                AND m.id > COALESCE(cr.last_read_message_id, 0)
+                AND m.sender_id <> $1
+                AND m.deleted_for_all = FALSE
+                AND m.deleted_by_receiver = FALSE) AS unread_count,
+            lm.id AS last_message_id,
+            lm.sender_id AS last_message_sender_id,
+            LEFT(lm.content, 200) AS last_message_content,
+            lm.created_at AS last_message_created_at,
+            COALESCE(lm.created_at, c.created_at) AS last_activity_at
+        FROM chats c
         LEFT JOIN chat_visibility cv ON cv.chat_id = c.id AND cv.user_id=$1
+        LEFT JOIN chat_reads cr ON cr.chat_id = c.id AND cr.user_id=$1
+        LEFT JOIN LATERAL (
+            SELECT m.id, m.sender_id, m.content, m.created_at FROM messages m
+            WHERE m.chat_id = c.id
+            AND m.deleted_for_all = FALSE
+            AND NOT (m.sender_id=$1 AND m.deleted_by_sender = TRUE)
+            AND NOT (m.sender_id<>$1 AND m.deleted_by_receiver = TRUE)
+            ORDER BY m.id DESC
+            LIMIT 1
+        ) lm ON TRUE
         WHERE (c.user1_id=$1 OR c.user2_id=$1) AND (cv.hidden IS NULL OR cv.hidden = FALSE)
-        ORDER BY created_at DESC`
+        ORDER BY last_activity_at DESC, c.id DESC`
 	rows, err := r.db.QueryxContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
@@ -96,15 +154,30 @@ func (r *ChatRepo) ListChats(ctx context.Context, userID int) ([]models.ChatSumm
 
 	var result []models.ChatSummary
 	for rows.Next() {
-		var chat models.Chat
-		if err := rows.StructScan(&chat); err != nil {
+		var row struct {
+			models.Chat
+			lastMessageRow
+			LastReadMessageID int       `db:"last_read_message_id"`
+			UnreadCount       int       `db:"unread_count"`
+			LastActivityAt    time.Time `db:"last_activity_at"`
+		}
+		if err := rows.StructScan(&row); err != nil {
 			return nil, err
 		}
-		friendID := chat.User1ID
+		friendID := row.User1ID
 		if friendID == userID {
-			friendID = chat.User2ID
+			friendID = row.User2ID
 		}
-		result = append(result, models.ChatSummary{ChatID: chat.ID, FriendID: friendID, Created: chat.CreatedAt})
+		result = append(result, models.ChatSummary{
+			ChatID:            row.ID,
+			FriendID:          friendID,
+			Created:           row.CreatedAt,
+			LastReadMessageID: row.LastReadMessageID,
+			UnreadCount:       row.UnreadCount,
+			LastMessage:       row.preview(),
+			LastActivityAt:    row.LastActivityAt,
+			ReadOnlyReason:    row.ReadOnlyReason,
+		})
 	}
 	return result, rows.Err()
 }
@@ -118,7 +191,40 @@ func (r *ChatRepo) HideChatForUser(ctx context.Context, chatID int, userID int)
 
 // UnhideChatForUser removes the hidden flag for the user.
 func (r *ChatRepo) UnhideChatForUser(ctx context.Context, chatID int, userID int) error {
-	_, err := r.db.ExecContext(ctx, `INSERT INTO chat_visibility (chat_id, user_id, hidden) VALUES ($1, $2, FALSE)
+	return unhideChat(ctx, r.db, chatID, userID)
+}
+
+func unhideChat(ctx context.Context, exec sqlx.ExecerContext, chatID int, userID int) error {
+	_, err := exec.ExecContext(ctx, `INSERT INTO chat_visibility (chat_id, user_id, hidden) VALUES ($1, $2, FALSE)
         ON CONFLICT (chat_id, user_id) DO UPDATE SET hidden = FALSE`, chatID, userID)
 	return err
 }
+
+// MarkRead advances the user's read marker to messageID (or the latest message when messageID is 0).
+// The marker never moves backwards; it returns the resulting marker and whether it advanced.
+func (r *ChatRepo) MarkRead(ctx context.Context, chatID int, userID int, messageID int) (int, bool, error) {
+	if messageID == 0 {
+		if err := r.db.GetContext(ctx, &messageID, `SELECT COALESCE(MAX(id), 0) FROM messages WHERE chat_id=$1`, chatID); err != nil {
+			return 0, false, err
+		}
+		if messageID == 0 {
+			// nothing to read yet
+			return 0, false, nil
+		}
+	}
+
+	var lastRead int
+	err := r.db.GetContext(ctx, &lastRead, `INSERT INTO chat_reads (chat_id, user_id, last_read_message_id) VALUES ($1, $2, $3)
+        ON CONFLICT (chat_id, user_id) DO UPDATE SET last_read_message_id = EXCLUDED.last_read_message_id, updated_at = NOW()
+        WHERE chat_reads.last_read_message_id < EXCLUDED.last_read_message_id
+        RETURNING last_read_message_id`, chatID, userID, messageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// marker already at or past messageID
+		err = r.db.GetContext(ctx, &lastRead, `SELECT last_read_message_id FROM chat_reads WHERE chat_id=$1 AND user_id=$2`, chatID, userID)
+		return lastRead, false, err
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return lastRead, true, nil
+}
@@ -4,67 +4,232 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
 
 	"github.com/jmoiron/sqlx"
 
+	"chat-service/internal/events"
 	"chat-service/internal/models"
+	"chat-service/internal/outbox"
 )
 
+const groupMessageColumns = `id, group_id, sender_id, content, kind, deleted_for_all, created_at, edited_at, reply_to_message_id`
+
 // GroupMessageRepository defines interactions for group messages.
 type GroupMessageRepository interface {
-	CreateGroupMessage(ctx context.Context, groupID int, senderID int, content string) (models.GroupMessage, error)
-	ListGroupMessages(ctx context.Context, groupID int) ([]models.GroupMessage, error)
+	CreateGroupMessage(ctx context.Context, groupID int, senderID int, content string, replyToID *int, attachmentIDs []int) (models.GroupMessage, error)
+	ListGroupMessages(ctx context.Context, groupID int, userID int, page PageRequest) ([]models.GroupMessage, int, error)
 	GetGroupMessage(ctx context.Context, messageID int) (models.GroupMessage, error)
-	DeleteForAll(ctx context.Context, messageID int, senderID int) error
+	DeleteForAll(ctx context.Context, messageID int, deletedBy int) (bool, error)
+	EditGroupMessage(ctx context.Context, messageID int, senderID int, content string) (models.GroupMessage, error)
+	AddReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error)
+	RemoveReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error)
 }
 
 // GroupMessageRepo is a sqlx-backed implementation.
 type GroupMessageRepo struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	outbox *outbox.Writer
 }
 
-// NewGroupMessageRepo constructs a GroupMessageRepo.
-func NewGroupMessageRepo(db *sqlx.DB) *GroupMessageRepo {
-	return &GroupMessageRepo{db: db}
+// NewGroupMessageRepo constructs a GroupMessageRepo recording domain events in events.
+func NewGroupMessageRepo(db *sqlx.DB, events *outbox.Writer) *GroupMessageRepo {
+	return &GroupMessageRepo{db: db, outbox: events}
 }
 
-// CreateGroupMessage persists a group message.
-func (r *GroupMessageRepo) CreateGroupMessage(ctx context.Context, groupID int, senderID int, content string) (models.GroupMessage, error) {
+// CreateGroupMessage persists a group message, optionally replying to another message and
+// carrying attachments previously uploaded by the sender.
+func (r *GroupMessageRepo) CreateGroupMessage(ctx context.Context, groupID int, senderID int, content string, replyToID *int, attachmentIDs []int) (models.GroupMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.GroupMessage{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
 	var msg models.GroupMessage
-	err := r.db.QueryRowxContext(ctx, `INSERT INTO group_messages (group_id, sender_id, content) VALUES ($1, $2, $3) RETURNING id, group_id, sender_id, content, deleted_for_all, created_at`, groupID, senderID, content).
-		Scan(&msg.ID, &msg.GroupID, &msg.SenderID, &msg.Content, &msg.DeletedForAll, &msg.CreatedAt)
+	if err = tx.QueryRowxContext(ctx, `INSERT INTO group_messages (group_id, sender_id, content, reply_to_message_id) VALUES ($1, $2, $3, $4) RETURNING `+groupMessageColumns, groupID, senderID, content, replyToID).
+		StructScan(&msg); err != nil {
+		return models.GroupMessage{}, err
+	}
+	if err = claimAttachments(ctx, tx, models.AttachmentScopeGroup, msg.ID, senderID, attachmentIDs); err != nil {
+		return models.GroupMessage{}, err
+	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeGroup, groupID), events.MessageCreated{
+		Scope:            events.ScopeGroup,
+		MessageID:        msg.ID,
+		Kind:             msg.Kind,
+		GroupID:          groupID,
+		SenderID:         senderID,
+		Content:          msg.Content,
+		ReplyToMessageID: msg.ReplyToMessageID,
+		AttachmentCount:  len(attachmentIDs),
+		CreatedAt:        msg.CreatedAt,
+	}, senderID); err != nil {
+		return models.GroupMessage{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.GroupMessage{}, err
+	}
+	msgs := []models.GroupMessage{msg}
+	if err := attachGroupQuotes(ctx, r.db, msgs, senderID); err != nil {
+		return models.GroupMessage{}, err
+	}
+	if err := attachGroupAttachments(ctx, r.db, msgs); err != nil {
+		return models.GroupMessage{}, err
+	}
+	return msgs[0], nil
+}
+
+// insertSystemMessage records a group change in the timeline on behalf of actorID within the
+// change's own transaction, so the message and its event commit or roll back with it.
+func insertSystemMessage(ctx context.Context, tx *sqlx.Tx, w *outbox.Writer, groupID int, actorID int, content string) (models.GroupMessage, error) {
+	var msg models.GroupMessage
+	if err := tx.QueryRowxContext(ctx, `INSERT INTO group_messages (group_id, sender_id, content, kind) VALUES ($1, $2, $3, $4) RETURNING `+groupMessageColumns, groupID, actorID, content, models.GroupMessageKindSystem).
+		StructScan(&msg); err != nil {
+		return models.GroupMessage{}, err
+	}
+	err := w.Add(ctx, tx, outbox.Aggregate(events.ScopeGroup, groupID), events.MessageCreated{
+		Scope:     events.ScopeGroup,
+		MessageID: msg.ID,
+		Kind:      msg.Kind,
+		GroupID:   groupID,
+		SenderID:  actorID,
+		Content:   msg.Content,
+		CreatedAt: msg.CreatedAt,
+	}, actorID)
 	return msg, err
 }
 
-// ListGroupMessages returns messages ordered by creation, excluding deleted_for_all.
-func (r *GroupMessageRepo) ListGroupMessages(ctx context.Context, groupID int) ([]models.GroupMessage, error) {
+// ListGroupMessages returns one page of messages excluding deleted_for_all and those sent by users
+// userID blocked, in ascending id order, together with the cursor for the next page (0 when
+// exhausted). Reactions are flagged for userID.
+func (r *GroupMessageRepo) ListGroupMessages(ctx context.Context, groupID int, userID int, page PageRequest) ([]models.GroupMessage, int, error) {
+	keyset, order, cursorArgs := page.keysetClause("id", 3)
+	query := `SELECT ` + groupMessageColumns + ` FROM group_messages m WHERE group_id=$1 AND deleted_for_all = FALSE AND ` + notBlockedSender("m", 2) +
+		keyset + order + ` LIMIT ` + strconv.Itoa(page.limit()+1)
+	args := append([]any{groupID, userID}, cursorArgs...)
 	var msgs []models.GroupMessage
-	err := r.db.SelectContext(ctx, &msgs, `SELECT id, group_id, sender_id, content, deleted_for_all, created_at FROM group_messages WHERE group_id=$1 AND deleted_for_all = FALSE ORDER BY created_at ASC`, groupID)
-	return msgs, err
+	if err := r.db.SelectContext(ctx, &msgs, query, args...); err != nil {
+		return nil, 0, err
+	}
+	msgs, next := trimPage(msgs, page, func(m models.GroupMessage) int { return m.ID })
+	if err := attachGroupQuotes(ctx, r.db, msgs, userID); err != nil {
+		return nil, 0, err
+	}
+	if err := attachGroupReactions(ctx, r.db, msgs, userID); err != nil {
+		return nil, 0, err
+	}
+	if err := attachGroupAttachments(ctx, r.db, msgs); err != nil {
+		return nil, 0, err
+	}
+	return msgs, next, nil
 }
 
 // GetGroupMessage fetches a single message.
 func (r *GroupMessageRepo) GetGroupMessage(ctx context.Context, messageID int) (models.GroupMessage, error) {
 	var msg models.GroupMessage
-	err := r.db.GetContext(ctx, &msg, `SELECT id, group_id, sender_id, content, deleted_for_all, created_at FROM group_messages WHERE id=$1`, messageID)
+	err := r.db.GetContext(ctx, &msg, `SELECT `+groupMessageColumns+` FROM group_messages WHERE id=$1`, messageID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return models.GroupMessage{}, ErrMessageNotFound
 	}
 	return msg, err
 }
 
-// DeleteForAll marks a message deleted for everyone (sender only).
-func (r *GroupMessageRepo) DeleteForAll(ctx context.Context, messageID int, senderID int) error {
-	res, err := r.db.ExecContext(ctx, `UPDATE group_messages SET deleted_for_all = TRUE WHERE id=$1 AND sender_id=$2`, messageID, senderID)
+// DeleteForAll marks a message deleted for everyone on behalf of deletedBy and reports whether it
+// was newly deleted; deleting it again writes no event. Callers enforce who may delete it.
+func (r *GroupMessageRepo) DeleteForAll(ctx context.Context, messageID int, deletedBy int) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return false, err
 	}
-	count, err := res.RowsAffected()
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var groupID int
+	err = tx.GetContext(ctx, &groupID, `UPDATE group_messages SET deleted_for_all = TRUE
+		WHERE id=$1 AND deleted_for_all = FALSE RETURNING group_id`, messageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		var deleted bool
+		if err = tx.GetContext(ctx, &deleted, `SELECT deleted_for_all FROM group_messages WHERE id=$1`, messageID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return false, ErrMessageNotFound
+			}
+			return false, err
+		}
+		return false, tx.Rollback()
+	}
+	if err != nil {
+		return false, err
+	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeGroup, groupID),
+		events.MessageDeletedForAll{Scope: events.ScopeGroup, MessageID: messageID, GroupID: groupID, DeletedBy: deletedBy}, deletedBy); err != nil {
+		return false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// EditGroupMessage replaces the content of a group message owned by senderID and records the previous revision.
+func (r *GroupMessageRepo) EditGroupMessage(ctx context.Context, messageID int, senderID int, content string) (models.GroupMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return models.GroupMessage{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = recordEdit(ctx, tx, "group_messages", editScopeGroup, messageID, senderID); err != nil {
+		return models.GroupMessage{}, err
+	}
+
+	var msg models.GroupMessage
+	if err = tx.QueryRowxContext(ctx, `UPDATE group_messages SET content=$2, edited_at=NOW() WHERE id=$1 RETURNING `+groupMessageColumns, messageID, content).
+		StructScan(&msg); err != nil {
+		return models.GroupMessage{}, err
+	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeGroup, msg.GroupID), events.MessageEdited{
+		Scope:     events.ScopeGroup,
+		MessageID: msg.ID,
+		GroupID:   msg.GroupID,
+		SenderID:  senderID,
+		Content:   msg.Content,
+		EditedAt:  *msg.EditedAt,
+	}, senderID); err != nil {
+		return models.GroupMessage{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.GroupMessage{}, err
 	}
-	if count == 0 {
-		return ErrMessageNotFound
+	msgs := []models.GroupMessage{msg}
+	if err := attachGroupQuotes(ctx, r.db, msgs, senderID); err != nil {
+		return models.GroupMessage{}, err
 	}
-	return nil
+	if err := attachGroupAttachments(ctx, r.db, msgs); err != nil {
+		return models.GroupMessage{}, err
+	}
+	return msgs[0], nil
+}
+
+// AddReaction records an emoji reaction and reports whether it was newly added.
+func (r *GroupMessageRepo) AddReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error) {
+	return addReaction(ctx, r.db, reactionScopeGroup, messageID, userID, emoji)
+}
+
+// RemoveReaction deletes an emoji reaction and reports whether it existed.
+func (r *GroupMessageRepo) RemoveReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error) {
+	return removeReaction(ctx, r.db, reactionScopeGroup, messageID, userID, emoji)
 }
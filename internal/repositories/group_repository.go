@@ -7,28 +7,58 @@ import (
 	"sort"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
+	"chat-service/internal/events"
 	"chat-service/internal/models"
+	"chat-service/internal/outbox"
 )
 
 var ErrGroupNotFound = errors.New("group not found")
 
+var ErrNotGroupMember = errors.New("user is not a group member")
+
+// ErrMemberRoleChanged is returned when a member's role no longer matches the one a change was
+// authorized against.
+var ErrMemberRoleChanged = errors.New("member role changed")
+
+// groupColumns lists the groups columns in models.Group order.
+const groupColumns = `id, name, description, avatar_url, owner_id, created_at`
+
+// GroupUpdate holds the group settings to change; nil fields are left untouched.
+type GroupUpdate struct {
+	Name        *string
+	Description *string
+	AvatarURL   *string
+}
+
 // GroupRepository abstracts group persistence.
 type GroupRepository interface {
 	CreateGroup(ctx context.Context, ownerID int, name string, memberIDs []int) (models.Group, error)
 	ListGroupsForUser(ctx context.Context, userID int) ([]models.Group, error)
 	IsMember(ctx context.Context, groupID int, userID int) (bool, error)
 	GetGroup(ctx context.Context, groupID int) (models.Group, error)
+	ListGroupSummaries(ctx context.Context, userID int) ([]models.GroupSummary, error)
+	MarkRead(ctx context.Context, groupID int, userID int, messageID int) (int, bool, error)
+	AddMembers(ctx context.Context, groupID int, userIDs []int, addedBy int, note func(added []int) string) ([]int, models.GroupMessage, error)
+	RemoveMember(ctx context.Context, groupID int, userID int, expectedRole string, removedBy int, note string) (models.GroupMessage, error)
+	LeaveGroup(ctx context.Context, groupID int, userID int, note func(newOwnerID int) string) (int, models.GroupMessage, error)
+	GetMemberRole(ctx context.Context, groupID int, userID int) (string, error)
+	SetMemberRole(ctx context.Context, groupID int, userID int, expectedRole string, role string, changedBy int, note string) (models.GroupMessage, error)
+	UpdateGroup(ctx context.Context, groupID int, update GroupUpdate, updatedBy int, note string) (models.Group, models.GroupMessage, error)
+	ListMembers(ctx context.Context, groupID int) ([]models.GroupMember, error)
+	ListGroupIDs(ctx context.Context, userID int) ([]int, error)
 }
 
 // GroupRepo is a sqlx implementation of GroupRepository.
 type GroupRepo struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	outbox *outbox.Writer
 }
 
-// NewGroupRepo constructs a GroupRepo.
-func NewGroupRepo(db *sqlx.DB) *GroupRepo {
-	return &GroupRepo{db: db}
+// NewGroupRepo constructs a GroupRepo recording domain events in events.
+func NewGroupRepo(db *sqlx.DB, events *outbox.Writer) *GroupRepo {
+	return &GroupRepo{db: db, outbox: events}
 }
 
 // CreateGroup creates a group and its members atomically.
@@ -44,8 +74,8 @@ func (r *GroupRepo) CreateGroup(ctx context.Context, ownerID int, name string, m
 	}()
 
 	var group models.Group
-	if err = tx.QueryRowxContext(ctx, `INSERT INTO groups (name, owner_id) VALUES ($1, $2) RETURNING id, name, owner_id, created_at`, name, ownerID).
-		Scan(&group.ID, &group.Name, &group.OwnerID, &group.CreatedAt); err != nil {
+	if err = tx.QueryRowxContext(ctx, `INSERT INTO groups (name, owner_id) VALUES ($1, $2) RETURNING `+groupColumns, name, ownerID).
+		StructScan(&group); err != nil {
 		return models.Group{}, err
 	}
 
@@ -61,10 +91,18 @@ func (r *GroupRepo) CreateGroup(ctx context.Context, ownerID int, name string, m
 	sort.Ints(ids)
 
 	for _, id := range ids {
-		if _, err = tx.ExecContext(ctx, `INSERT INTO group_members (group_id, user_id) VALUES ($1, $2)`, group.ID, id); err != nil {
+		role := models.GroupRoleMember
+		if id == ownerID {
+			role = models.GroupRoleOwner
+		}
+		if _, err = tx.ExecContext(ctx, `INSERT INTO group_members (group_id, user_id, role) VALUES ($1, $2, $3)`, group.ID, id, role); err != nil {
 			return models.Group{}, err
 		}
 	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeGroup, group.ID),
+		events.GroupCreated{GroupID: group.ID, Name: group.Name, OwnerID: ownerID, MemberIDs: ids}, ownerID); err != nil {
+		return models.Group{}, err
+	}
 
 	if err = tx.Commit(); err != nil {
 		return models.Group{}, err
@@ -75,10 +113,17 @@ func (r *GroupRepo) CreateGroup(ctx context.Context, ownerID int, name string, m
 // ListGroupsForUser returns groups that include the user.
 func (r *GroupRepo) ListGroupsForUser(ctx context.Context, userID int) ([]models.Group, error) {
 	var groups []models.Group
-	err := r.db.SelectContext(ctx, &groups, `SELECT g.id, g.name, g.owner_id, g.created_at FROM groups g INNER JOIN group_members gm ON gm.group_id = g.id WHERE gm.user_id=$1 ORDER BY g.created_at DESC`, userID)
+	err := r.db.SelectContext(ctx, &groups, `SELECT g.id, g.name, g.description, g.avatar_url, g.owner_id, g.created_at FROM groups g INNER JOIN group_members gm ON gm.group_id = g.id WHERE gm.user_id=$1 ORDER BY g.created_at DESC`, userID)
 	return groups, err
 }
 
+// ListGroupIDs returns the ids of the groups that include the user.
+func (r *GroupRepo) ListGroupIDs(ctx context.Context, userID int) ([]int, error) {
+	var ids []int
+	err := r.db.SelectContext(ctx, &ids, `SELECT group_id FROM group_members WHERE user_id=$1`, userID)
+	return ids, err
+}
+
 // IsMember checks membership.
 func (r *GroupRepo) IsMember(ctx context.Context, groupID int, userID int) (bool, error) {
 	var exists bool
@@ -89,9 +134,354 @@ func (r *GroupRepo) IsMember(ctx context.Context, groupID int, userID int) (bool
 // GetGroup fetches a single group.
 func (r *GroupRepo) GetGroup(ctx context.Context, groupID int) (models.Group, error) {
 	var group models.Group
-	err := r.db.GetContext(ctx, &group, `SELECT id, name, owner_id, created_at FROM groups WHERE id=$1`, groupID)
+	err := r.db.GetContext(ctx, &group, `SELECT `+groupColumns+` FROM groups WHERE id=$1`, groupID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return models.Group{}, ErrGroupNotFound
 	}
 	return group, err
 }
+
+// ListGroupSummaries returns groups that include the user along with the user's read state and
+// a preview of the latest message, most recently active first. Messages of users the user
+// blocked are not counted or previewed.
+func (r *GroupRepo) ListGroupSummaries(ctx context.Context, userID int) ([]models.GroupSummary, error) {
+	var rows []struct {
+		models.GroupSummary
+		lastMessageRow
+	}
+	err := r.db.SelectContext(ctx, &rows, `SELECT g.id, g.name, g.description, g.avatar_url, g.owner_id, g.created_at,
+            COALESCE(gr.last_read_message_id, 0) AS last_read_message_id,
+            (SELECT COUNT(*) FROM group_messages m
+                WHERE m.group_id = g.id
+                AND m.id > COALESCE(gr.last_read_message_id, 0)
+                AND m.sender_id <> $1
+                AND m.deleted_for_all = FALSE
+                AND `+notBlockedSender("m", 1)+`) AS unread_count,
+            lm.id AS last_message_id,
+            lm.sender_id AS last_message_sender_id,
+            LEFT(lm.content, 200) AS last_message_content,
+            lm.created_at AS last_message_created_at,
+            COALESCE(lm.created_at, g.created_at) AS last_activity_at
+        FROM groups g
+        INNER JOIN group_members gm ON gm.group_id = g.id
+        LEFT JOIN group_reads gr ON gr.group_id = g.id AND gr.user_id = $1
+        LEFT JOIN LATERAL (
+            SELECT m.id, m.sender_id, m.content, m.created_at FROM group_messages m
+            WHERE m.group_id = g.id AND m.deleted_for_all = FALSE
+            AND `+notBlockedSender("m", 1)+`
+            ORDER BY m.id DESC
+            LIMIT 1
+        ) lm ON TRUE
+        WHERE gm.user_id=$1
+        ORDER BY last_activity_at DESC, g.id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]models.GroupSummary, 0, len(rows))
+	for _, row := range rows {
+		summary := row.GroupSummary
+		summary.LastMessage = row.preview()
+		groups = append(groups, summary)
+	}
+	return groups, nil
+}
+
+// MarkRead advances the user's read marker to messageID (or the latest message when messageID is 0).
+// The marker never moves backwards; it returns the resulting marker and whether it advanced.
+func (r *GroupRepo) MarkRead(ctx context.Context, groupID int, userID int, messageID int) (int, bool, error) {
+	if messageID == 0 {
+		if err := r.db.GetContext(ctx, &messageID, `SELECT COALESCE(MAX(id), 0) FROM group_messages WHERE group_id=$1`, groupID); err != nil {
+			return 0, false, err
+		}
+		if messageID == 0 {
+			// nothing to read yet
+			return 0, false, nil
+		}
+	}
+
+	var lastRead int
+	err := r.db.GetContext(ctx, &lastRead, `INSERT INTO group_reads (group_id, user_id, last_read_message_id) VALUES ($1, $2, $3)
+        ON CONFLICT (group_id, user_id) DO UPDATE SET last_read_message_id = EXCLUDED.last_read_message_id, updated_at = NOW()
+        WHERE group_reads.last_read_message_id < EXCLUDED.last_read_message_id
+        RETURNING last_read_message_id`, groupID, userID, messageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// marker already at or past messageID
+		err = r.db.GetContext(ctx, &lastRead, `SELECT last_read_message_id FROM group_reads WHERE group_id=$1 AND user_id=$2`, groupID, userID)
+		return lastRead, false, err
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return lastRead, true, nil
+}
+
+// AddMembers adds users to a group on behalf of addedBy, ignoring existing members, and returns
+// the ids actually added. When any were added, note renders the system message recorded in the
+// same transaction, which is returned as well.
+func (r *GroupRepo) AddMembers(ctx context.Context, groupID int, userIDs []int, addedBy int, note func(added []int) string) ([]int, models.GroupMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, models.GroupMessage{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var added []int
+	if err = tx.SelectContext(ctx, &added, `INSERT INTO group_members (group_id, user_id) SELECT $1, UNNEST($2::int[])
+        ON CONFLICT (group_id, user_id) DO NOTHING
+        RETURNING user_id`, groupID, pq.Array(userIDs)); err != nil {
+		return nil, models.GroupMessage{}, err
+	}
+	sort.Ints(added)
+	var msg models.GroupMessage
+	if len(added) > 0 {
+		if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeGroup, groupID),
+			events.GroupMemberAdded{GroupID: groupID, UserIDs: added, AddedBy: addedBy}, addedBy); err != nil {
+			return nil, models.GroupMessage{}, err
+		}
+		if msg, err = insertSystemMessage(ctx, tx, r.outbox, groupID, addedBy, note(added)); err != nil {
+			return nil, models.GroupMessage{}, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, models.GroupMessage{}, err
+	}
+	return added, msg, nil
+}
+
+// RemoveMember deletes a membership on behalf of removedBy and records note in the timeline,
+// returning the system message. The member must still hold expectedRole, the role the removal
+// was authorized against; otherwise ErrMemberRoleChanged is returned.
+func (r *GroupRepo) RemoveMember(ctx context.Context, groupID int, userID int, expectedRole string, removedBy int, note string) (models.GroupMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.GroupMessage{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = lockMemberRole(ctx, tx, groupID, userID, expectedRole); err != nil {
+		return models.GroupMessage{}, err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM group_members WHERE group_id=$1 AND user_id=$2`, groupID, userID); err != nil {
+		return models.GroupMessage{}, err
+	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeGroup, groupID),
+		events.GroupMemberRemoved{GroupID: groupID, UserID: userID, RemovedBy: removedBy, Reason: events.RemovalKicked}, removedBy); err != nil {
+		return models.GroupMessage{}, err
+	}
+	msg, err := insertSystemMessage(ctx, tx, r.outbox, groupID, removedBy, note)
+	if err != nil {
+		return models.GroupMessage{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.GroupMessage{}, err
+	}
+	return msg, nil
+}
+
+// LeaveGroup removes the user from the group. When the owner leaves, ownership passes to the
+// longest-standing admin, or the longest-standing member when there are no admins;
+// the new owner id is returned (0 when ownership did not change) together with the system
+// message note renders for it, recorded in the same transaction.
+func (r *GroupRepo) LeaveGroup(ctx context.Context, groupID int, userID int, note func(newOwnerID int) string) (int, models.GroupMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, models.GroupMessage{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	newOwnerID, err := leaveGroup(ctx, tx, groupID, userID)
+	if err != nil {
+		return 0, models.GroupMessage{}, err
+	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeGroup, groupID),
+		events.GroupMemberRemoved{GroupID: groupID, UserID: userID, RemovedBy: userID, Reason: events.RemovalLeft, NewOwnerID: newOwnerID}, userID); err != nil {
+		return 0, models.GroupMessage{}, err
+	}
+	msg, err := insertSystemMessage(ctx, tx, r.outbox, groupID, userID, note(newOwnerID))
+	if err != nil {
+		return 0, models.GroupMessage{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, models.GroupMessage{}, err
+	}
+	return newOwnerID, msg, nil
+}
+
+// lockMemberRole locks userID's membership until tx ends and checks that they still hold
+// expectedRole, returning ErrNotGroupMember or ErrMemberRoleChanged otherwise.
+func lockMemberRole(ctx context.Context, tx *sqlx.Tx, groupID int, userID int, expectedRole string) error {
+	var role string
+	err := tx.GetContext(ctx, &role, `SELECT role FROM group_members WHERE group_id=$1 AND user_id=$2 FOR UPDATE`, groupID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotGroupMember
+	}
+	if err != nil {
+		return err
+	}
+	if role != expectedRole {
+		return ErrMemberRoleChanged
+	}
+	return nil
+}
+
+// leaveGroup removes userID from the group within tx, passing ownership on when the owner leaves,
+// and returns the new owner id (0 when ownership did not change).
+func leaveGroup(ctx context.Context, tx *sqlx.Tx, groupID int, userID int) (int, error) {
+	var ownerID int
+	if err := tx.GetContext(ctx, &ownerID, `SELECT owner_id FROM groups WHERE id=$1 FOR UPDATE`, groupID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrGroupNotFound
+		}
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM group_members WHERE group_id=$1 AND user_id=$2`, groupID, userID)
+	if err != nil {
+		return 0, err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, ErrNotGroupMember
+	}
+
+	newOwnerID := 0
+	if ownerID == userID {
+		err := tx.GetContext(ctx, &newOwnerID, `SELECT user_id FROM group_members WHERE group_id=$1
+            ORDER BY (role = $2) DESC, joined_at ASC, user_id ASC LIMIT 1`, groupID, models.GroupRoleAdmin)
+		if errors.Is(err, sql.ErrNoRows) {
+			// last member left; the group is kept without members
+			return 0, nil
+		} else if err != nil {
+			return 0, err
+		} else if _, err = tx.ExecContext(ctx, `UPDATE groups SET owner_id=$2 WHERE id=$1`, groupID, newOwnerID); err != nil {
+			return 0, err
+		} else if _, err = tx.ExecContext(ctx, `UPDATE group_members SET role=$3 WHERE group_id=$1 AND user_id=$2`, groupID, newOwnerID, models.GroupRoleOwner); err != nil {
+			return 0, err
+		}
+	}
+	return newOwnerID, nil
+}
+
+// GetMemberRole returns the user's role in the group, or ErrNotGroupMember.
+func (r *GroupRepo) GetMemberRole(ctx context.Context, groupID int, userID int) (string, error) {
+	var role string
+	err := r.db.GetContext(ctx, &role, `SELECT role FROM group_members WHERE group_id=$1 AND user_id=$2`, groupID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotGroupMember
+	}
+	return role, err
+}
+
+// SetMemberRole changes a member's role from expectedRole to role on behalf of changedBy and
+// records note in the timeline, returning the system message. ErrMemberRoleChanged is returned
+// when the member no longer holds expectedRole. Ownership is only moved through LeaveGroup.
+func (r *GroupRepo) SetMemberRole(ctx context.Context, groupID int, userID int, expectedRole string, role string, changedBy int, note string) (models.GroupMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.GroupMessage{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if expectedRole == models.GroupRoleOwner {
+		err = ErrMemberRoleChanged
+		return models.GroupMessage{}, err
+	}
+	if err = lockMemberRole(ctx, tx, groupID, userID, expectedRole); err != nil {
+		return models.GroupMessage{}, err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE group_members SET role=$3 WHERE group_id=$1 AND user_id=$2`, groupID, userID, role); err != nil {
+		return models.GroupMessage{}, err
+	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeGroup, groupID),
+		events.GroupMemberRoleChanged{GroupID: groupID, UserID: userID, Role: role, ChangedBy: changedBy}, changedBy); err != nil {
+		return models.GroupMessage{}, err
+	}
+	msg, err := insertSystemMessage(ctx, tx, r.outbox, groupID, changedBy, note)
+	if err != nil {
+		return models.GroupMessage{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.GroupMessage{}, err
+	}
+	return msg, nil
+}
+
+// UpdateGroup applies the non-nil fields of update on behalf of updatedBy and returns the resulting
+// group. A non-empty note is recorded in the timeline in the same transaction and returned.
+func (r *GroupRepo) UpdateGroup(ctx context.Context, groupID int, update GroupUpdate, updatedBy int, note string) (models.Group, models.GroupMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Group{}, models.GroupMessage{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var group models.Group
+	if err = tx.GetContext(ctx, &group, `UPDATE groups SET
+            name = COALESCE($2, name),
+            description = COALESCE($3, description),
+            avatar_url = COALESCE($4, avatar_url)
+        WHERE id=$1
+        RETURNING `+groupColumns, groupID, update.Name, update.Description, update.AvatarURL); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Group{}, models.GroupMessage{}, ErrGroupNotFound
+		}
+		return models.Group{}, models.GroupMessage{}, err
+	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeGroup, groupID), events.GroupUpdated{
+		GroupID:     groupID,
+		Name:        group.Name,
+		Description: group.Description,
+		AvatarURL:   group.AvatarURL,
+		UpdatedBy:   updatedBy,
+	}, updatedBy); err != nil {
+		return models.Group{}, models.GroupMessage{}, err
+	}
+	var msg models.GroupMessage
+	if note != "" {
+		if msg, err = insertSystemMessage(ctx, tx, r.outbox, groupID, updatedBy, note); err != nil {
+			return models.Group{}, models.GroupMessage{}, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Group{}, models.GroupMessage{}, err
+	}
+	return group, msg, nil
+}
+
+// ListMembers returns the group's members, owner first, then admins, then by join time.
+func (r *GroupRepo) ListMembers(ctx context.Context, groupID int) ([]models.GroupMember, error) {
+	var members []models.GroupMember
+	err := r.db.SelectContext(ctx, &members, `SELECT user_id, role, joined_at FROM group_members WHERE group_id=$1
+        ORDER BY CASE role WHEN $2 THEN 0 WHEN $3 THEN 1 ELSE 2 END, joined_at ASC, user_id ASC`,
+		groupID, models.GroupRoleOwner, models.GroupRoleAdmin)
+	return members, err
+}
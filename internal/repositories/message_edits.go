@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	editScopeChat  = "chat"
+	editScopeGroup = "group"
+)
+
+// recordEdit locks a live message owned by senderID and copies its current content into message_edits.
+// It returns ErrMessageNotFound when the message does not exist, belongs to someone else or was deleted for all.
+func recordEdit(ctx context.Context, tx *sqlx.Tx, table, scope string, messageID int, senderID int) error {
+	var previous string
+	err := tx.GetContext(ctx, &previous, `SELECT content FROM `+table+` WHERE id=$1 AND sender_id=$2 AND deleted_for_all = FALSE FOR UPDATE`, messageID, senderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrMessageNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO message_edits (scope, message_id, editor_id, previous_content) VALUES ($1, $2, $3, $4)`, scope, messageID, senderID, previous)
+	return err
+}
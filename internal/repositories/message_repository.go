@@ -4,59 +4,126 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
 
 	"github.com/jmoiron/sqlx"
 
+	"chat-service/internal/events"
 	"chat-service/internal/models"
+	"chat-service/internal/outbox"
 )
 
 var ErrMessageNotFound = errors.New("message not found")
 
+const messageColumns = `id, chat_id, sender_id, content, deleted_by_sender, deleted_by_receiver, deleted_for_all, created_at, edited_at, reply_to_message_id`
+
 // MessageRepository defines interactions for chat messages.
 type MessageRepository interface {
-	CreateChatMessage(ctx context.Context, chatID int, senderID int, content string) (models.Message, error)
-	GetChatMessagesForUser(ctx context.Context, chatID int, userID int) ([]models.Message, error)
+	CreateChatMessage(ctx context.Context, chatID int, senderID int, content string, replyToID *int, attachmentIDs []int) (models.Message, error)
+	GetChatMessagesForUser(ctx context.Context, chatID int, userID int, page PageRequest) ([]models.Message, int, error)
 	GetMessage(ctx context.Context, messageID int) (models.Message, error)
 	SoftDeleteMessageForUser(ctx context.Context, messageID int, isSender bool) error
-	DeleteMessageForAll(ctx context.Context, messageID int, userID int) error
+	DeleteMessageForAll(ctx context.Context, messageID int, userID int) (bool, error)
+	EditMessage(ctx context.Context, messageID int, senderID int, content string) (models.Message, error)
+	AddReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error)
+	RemoveReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error)
 }
 
 // MessageRepo is a sqlx-backed repository.
 type MessageRepo struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	outbox *outbox.Writer
 }
 
-// NewMessageRepo constructs MessageRepo.
-func NewMessageRepo(db *sqlx.DB) *MessageRepo {
-	return &MessageRepo{db: db}
+// NewMessageRepo constructs MessageRepo recording domain events in events.
+func NewMessageRepo(db *sqlx.DB, events *outbox.Writer) *MessageRepo {
+	return &MessageRepo{db: db, outbox: events}
 }
 
-// CreateChatMessage stores a message in a private chat.
-func (r *MessageRepo) CreateChatMessage(ctx context.Context, chatID int, senderID int, content string) (models.Message, error) {
+// CreateChatMessage stores a message in a private chat, optionally replying to another message and
+// carrying attachments previously uploaded by the sender.
+func (r *MessageRepo) CreateChatMessage(ctx context.Context, chatID int, senderID int, content string, replyToID *int, attachmentIDs []int) (models.Message, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Message{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
 	var msg models.Message
-	err := r.db.QueryRowxContext(ctx, `INSERT INTO messages (chat_id, sender_id, content) VALUES ($1, $2, $3) RETURNING id, chat_id, sender_id, content, deleted_by_sender, deleted_by_receiver, deleted_for_all, created_at`, chatID, senderID, content).
-		Scan(&msg.ID, &msg.ChatID, &msg.SenderID, &msg.Content, &msg.DeletedBySender, &msg.DeletedByReceiver, &msg.DeletedForAll, &msg.CreatedAt)
-	return msg, err
+	if err = tx.QueryRowxContext(ctx, `INSERT INTO messages (chat_id, sender_id, content, reply_to_message_id) VALUES ($1, $2, $3, $4) RETURNING `+messageColumns, chatID, senderID, content, replyToID).
+		StructScan(&msg); err != nil {
+		return models.Message{}, err
+	}
+	if err = claimAttachments(ctx, tx, models.AttachmentScopeChat, msg.ID, senderID, attachmentIDs); err != nil {
+		return models.Message{}, err
+	}
+	var recipientID int
+	if err = tx.GetContext(ctx, &recipientID, `SELECT CASE WHEN user1_id=$2 THEN user2_id ELSE user1_id END FROM chats WHERE id=$1`, chatID, senderID); err != nil {
+		return models.Message{}, err
+	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeChat, chatID), events.MessageCreated{
+		Scope:            events.ScopeChat,
+		MessageID:        msg.ID,
+		ChatID:           chatID,
+		SenderID:         senderID,
+		RecipientIDs:     []int{recipientID},
+		Content:          msg.Content,
+		ReplyToMessageID: msg.ReplyToMessageID,
+		AttachmentCount:  len(attachmentIDs),
+		CreatedAt:        msg.CreatedAt,
+	}, senderID); err != nil {
+		return models.Message{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Message{}, err
+	}
+	msgs := []models.Message{msg}
+	if err := attachChatQuotes(ctx, r.db, msgs, senderID); err != nil {
+		return models.Message{}, err
+	}
+	if err := attachChatAttachments(ctx, r.db, msgs); err != nil {
+		return models.Message{}, err
+	}
+	return msgs[0], nil
 }
 
-// GetChatMessagesForUser returns ordered chat messages filtered per user visibility rules.
-func (r *MessageRepo) GetChatMessagesForUser(ctx context.Context, chatID int, userID int) ([]models.Message, error) {
-	query := `SELECT id, chat_id, sender_id, content, deleted_by_sender, deleted_by_receiver, deleted_for_all, created_at
+// GetChatMessagesForUser returns one page of chat messages filtered per user visibility rules,
+// in ascending id order, together with the cursor for the next page (0 when exhausted).
+func (r *MessageRepo) GetChatMessagesForUser(ctx context.Context, chatID int, userID int, page PageRequest) ([]models.Message, int, error) {
+	keyset, order, cursorArgs := page.keysetClause("id", 3)
+	query := `SELECT ` + messageColumns + `
         FROM messages
         WHERE chat_id=$1
         AND deleted_for_all = FALSE
         AND NOT (sender_id=$2 AND deleted_by_sender = TRUE)
-        AND NOT (sender_id<>$2 AND deleted_by_receiver = TRUE)
-        ORDER BY created_at ASC`
+        AND NOT (sender_id<>$2 AND deleted_by_receiver = TRUE)` + keyset + order + ` LIMIT ` + strconv.Itoa(page.limit()+1)
+	args := append([]any{chatID, userID}, cursorArgs...)
 	var msgs []models.Message
-	err := r.db.SelectContext(ctx, &msgs, query, chatID, userID)
-	return msgs, err
+	if err := r.db.SelectContext(ctx, &msgs, query, args...); err != nil {
+		return nil, 0, err
+	}
+	msgs, next := trimPage(msgs, page, func(m models.Message) int { return m.ID })
+	if err := attachChatQuotes(ctx, r.db, msgs, userID); err != nil {
+		return nil, 0, err
+	}
+	if err := attachChatReactions(ctx, r.db, msgs, userID); err != nil {
+		return nil, 0, err
+	}
+	if err := attachChatAttachments(ctx, r.db, msgs); err != nil {
+		return nil, 0, err
+	}
+	return msgs, next, nil
 }
 
 // GetMessage retrieves a single message.
 func (r *MessageRepo) GetMessage(ctx context.Context, messageID int) (models.Message, error) {
 	var msg models.Message
-	err := r.db.GetContext(ctx, &msg, `SELECT id, chat_id, sender_id, content, deleted_by_sender, deleted_by_receiver, deleted_for_all, created_at FROM messages WHERE id=$1`, messageID)
+	err := r.db.GetContext(ctx, &msg, `SELECT `+messageColumns+` FROM messages WHERE id=$1`, messageID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return models.Message{}, ErrMessageNotFound
 	}
@@ -73,18 +140,96 @@ func (r *MessageRepo) SoftDeleteMessageForUser(ctx context.Context, messageID in
 	return err
 }
 
-// DeleteMessageForAll marks a message as deleted for everyone.
-func (r *MessageRepo) DeleteMessageForAll(ctx context.Context, messageID int, userID int) error {
-	res, err := r.db.ExecContext(ctx, `UPDATE messages SET deleted_for_all = TRUE WHERE id=$1 AND sender_id=$2`, messageID, userID)
+// DeleteMessageForAll marks a message sent by userID as deleted for everyone and reports whether
+// it was newly deleted; deleting it again writes no event.
+func (r *MessageRepo) DeleteMessageForAll(ctx context.Context, messageID int, userID int) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var chatID int
+	err = tx.GetContext(ctx, &chatID, `UPDATE messages SET deleted_for_all = TRUE
+		WHERE id=$1 AND sender_id=$2 AND deleted_for_all = FALSE RETURNING chat_id`, messageID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		var deleted bool
+		if err = tx.GetContext(ctx, &deleted, `SELECT deleted_for_all FROM messages WHERE id=$1 AND sender_id=$2`, messageID, userID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return false, ErrMessageNotFound
+			}
+			return false, err
+		}
+		return false, tx.Rollback()
 	}
-	count, err := res.RowsAffected()
 	if err != nil {
-		return err
+		return false, err
+	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeChat, chatID),
+		events.MessageDeletedForAll{Scope: events.ScopeChat, MessageID: messageID, ChatID: chatID, DeletedBy: userID}, userID); err != nil {
+		return false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// EditMessage replaces the content of a message owned by senderID and records the previous revision.
+func (r *MessageRepo) EditMessage(ctx context.Context, messageID int, senderID int, content string) (models.Message, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Message{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = recordEdit(ctx, tx, "messages", editScopeChat, messageID, senderID); err != nil {
+		return models.Message{}, err
+	}
+
+	var msg models.Message
+	if err = tx.QueryRowxContext(ctx, `UPDATE messages SET content=$2, edited_at=NOW() WHERE id=$1 RETURNING `+messageColumns, messageID, content).
+		StructScan(&msg); err != nil {
+		return models.Message{}, err
+	}
+	if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeChat, msg.ChatID), events.MessageEdited{
+		Scope:     events.ScopeChat,
+		MessageID: msg.ID,
+		ChatID:    msg.ChatID,
+		SenderID:  senderID,
+		Content:   msg.Content,
+		EditedAt:  *msg.EditedAt,
+	}, senderID); err != nil {
+		return models.Message{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Message{}, err
 	}
-	if count == 0 {
-		return ErrMessageNotFound
+	msgs := []models.Message{msg}
+	if err := attachChatQuotes(ctx, r.db, msgs, senderID); err != nil {
+		return models.Message{}, err
 	}
-	return nil
+	if err := attachChatAttachments(ctx, r.db, msgs); err != nil {
+		return models.Message{}, err
+	}
+	return msgs[0], nil
+}
+
+// AddReaction records an emoji reaction and reports whether it was newly added.
+func (r *MessageRepo) AddReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error) {
+	return addReaction(ctx, r.db, reactionScopeChat, messageID, userID, emoji)
+}
+
+// RemoveReaction deletes an emoji reaction and reports whether it existed.
+func (r *MessageRepo) RemoveReaction(ctx context.Context, messageID int, userID int, emoji string) (bool, error) {
+	return removeReaction(ctx, r.db, reactionScopeChat, messageID, userID, emoji)
 }
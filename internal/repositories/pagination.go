@@ -0,0 +1,69 @@
+package repositories
+
+import "strconv"
+
+// PageRequest describes a keyset page over message ids.
+// Before and After are exclusive message id cursors; at most one of them may be set.
+// When neither is set the most recent messages are returned.
+type PageRequest struct {
+	Before int
+	After  int
+	Limit  int
+}
+
+// DefaultPageLimit is used when a request does not specify a limit.
+const DefaultPageLimit = 50
+
+// MaxPageLimit caps the number of messages returned in one page.
+const MaxPageLimit = 100
+
+func (p PageRequest) limit() int {
+	if p.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return p.Limit
+}
+
+// forward reports whether the page walks towards newer messages.
+func (p PageRequest) forward() bool {
+	return p.After > 0
+}
+
+// keysetClause returns the id predicate, ordering and arguments for the page.
+// argIndex is the placeholder position used for the cursor value.
+func (p PageRequest) keysetClause(column string, argIndex int) (string, string, []any) {
+	placeholder := "$" + strconv.Itoa(argIndex)
+	switch {
+	case p.After > 0:
+		return " AND " + column + " > " + placeholder, " ORDER BY " + column + " ASC", []any{p.After}
+	case p.Before > 0:
+		return " AND " + column + " < " + placeholder, " ORDER BY " + column + " DESC", []any{p.Before}
+	default:
+		return "", " ORDER BY " + column + " DESC", nil
+	}
+}
+
+// trimPage cuts the extra look-ahead row, restores ascending order and
+// returns the cursor for the following page (0 when there are no more rows).
+func trimPage[T any](rows []T, p PageRequest, id func(T) int) ([]T, int) {
+	limit := p.limit()
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+	if !p.forward() {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if !hasMore || len(rows) == 0 {
+		return rows, 0
+	}
+	if p.forward() {
+		return rows, id(rows[len(rows)-1])
+	}
+	return rows, id(rows[0])
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PresenceRepository persists last-seen timestamps and resolves who may see a user's presence.
+type PresenceRepository interface {
+	TouchLastSeen(ctx context.Context, userID int, at time.Time) error
+	LastSeen(ctx context.Context, userIDs []int) (map[int]time.Time, error)
+	ContactIDs(ctx context.Context, userID int) ([]int, error)
+}
+
+// PresenceRepo is a sqlx implementation of PresenceRepository.
+type PresenceRepo struct {
+	db *sqlx.DB
+}
+
+// NewPresenceRepo constructs a PresenceRepo.
+func NewPresenceRepo(db *sqlx.DB) *PresenceRepo {
+	return &PresenceRepo{db: db}
+}
+
+// TouchLastSeen records that the user was last seen at the given time. Older timestamps are ignored.
+func (r *PresenceRepo) TouchLastSeen(ctx context.Context, userID int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO user_presence (user_id, last_seen_at) VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE SET last_seen_at = EXCLUDED.last_seen_at
+        WHERE user_presence.last_seen_at < EXCLUDED.last_seen_at`, userID, at)
+	return err
+}
+
+// LastSeen returns the stored last-seen timestamps; users never seen are absent from the map.
+func (r *PresenceRepo) LastSeen(ctx context.Context, userIDs []int) (map[int]time.Time, error) {
+	var rows []struct {
+		UserID     int       `db:"user_id"`
+		LastSeenAt time.Time `db:"last_seen_at"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT user_id, last_seen_at FROM user_presence WHERE user_id = ANY($1)`, pq.Array(userIDs)); err != nil {
+		return nil, err
+	}
+	seen := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		seen[row.UserID] = row.LastSeenAt
+	}
+	return seen, nil
+}
+
+// ContactIDs returns the users the given user shares a chat or a group with.
+func (r *PresenceRepo) ContactIDs(ctx context.Context, userID int) ([]int, error) {
+	var ids []int
+	err := r.db.SelectContext(ctx, &ids, `SELECT CASE WHEN user1_id = $1 THEN user2_id ELSE user1_id END FROM chats
+            WHERE user1_id = $1 OR user2_id = $1
+        UNION
+        SELECT other.user_id FROM group_members me
+            INNER JOIN group_members other ON other.group_id = me.group_id
+            WHERE me.user_id = $1 AND other.user_id <> $1`, userID)
+	return ids, err
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"chat-service/internal/models"
+)
+
+// lastMessageRow holds the nullable columns produced by the last-message LATERAL joins.
+type lastMessageRow struct {
+	LastMessageID        sql.NullInt64  `db:"last_message_id"`
+	LastMessageSenderID  sql.NullInt64  `db:"last_message_sender_id"`
+	LastMessageContent   sql.NullString `db:"last_message_content"`
+	LastMessageCreatedAt sql.NullTime   `db:"last_message_created_at"`
+}
+
+func (r lastMessageRow) preview() *models.MessagePreview {
+	if !r.LastMessageID.Valid {
+		return nil
+	}
+	return &models.MessagePreview{
+		ID:        int(r.LastMessageID.Int64),
+		SenderID:  int(r.LastMessageSenderID.Int64),
+		Snippet:   models.Snippet(r.LastMessageContent.String, models.PreviewMaxRunes),
+		CreatedAt: r.LastMessageCreatedAt.Time,
+	}
+}
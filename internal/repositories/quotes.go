@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"chat-service/internal/models"
+)
+
+type quotedRow struct {
+	ID       int    `db:"id"`
+	SenderID int    `db:"sender_id"`
+	Content  string `db:"content"`
+	Hidden   bool   `db:"hidden"`
+}
+
+// Predicates over a quoted message aliased m that hide it from the viewer bound to $2, matching
+// the filters applied when the viewer lists the conversation.
+const chatQuoteHidden = `(m.sender_id = $2 AND m.deleted_by_sender) OR (m.sender_id <> $2 AND m.deleted_by_receiver)`
+
+var groupQuoteHidden = `NOT ` + notBlockedSender("m", 2)
+
+// loadQuotes fetches snapshots of the referenced messages from table in a single query. Messages
+// deleted for everyone or matching hidden for viewerID are returned as tombstones.
+func loadQuotes(ctx context.Context, q sqlx.QueryerContext, table, hidden string, viewerID int, ids []int) (map[int]*models.QuotedMessage, error) {
+	quotes := map[int]*models.QuotedMessage{}
+	if len(ids) == 0 {
+		return quotes, nil
+	}
+
+	var rows []quotedRow
+	query := `SELECT m.id, m.sender_id, m.content, (m.deleted_for_all OR ` + hidden + `) AS hidden FROM ` + table + ` m WHERE m.id = ANY($1)`
+	if err := sqlx.SelectContext(ctx, q, &rows, query, pq.Array(ids), viewerID); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		quotes[row.ID] = models.NewQuotedMessage(row.ID, row.SenderID, row.Content, row.Hidden)
+	}
+	return quotes, nil
+}
+
+// attachChatQuotes fills ReplyTo on msgs as seen by viewerID.
+func attachChatQuotes(ctx context.Context, q sqlx.QueryerContext, msgs []models.Message, viewerID int) error {
+	ids := make([]int, 0, len(msgs))
+	for _, m := range msgs {
+		if m.ReplyToMessageID != nil {
+			ids = append(ids, *m.ReplyToMessageID)
+		}
+	}
+	quotes, err := loadQuotes(ctx, q, "messages", chatQuoteHidden, viewerID, ids)
+	if err != nil {
+		return err
+	}
+	for i := range msgs {
+		if msgs[i].ReplyToMessageID != nil {
+			msgs[i].ReplyTo = quotes[*msgs[i].ReplyToMessageID]
+		}
+	}
+	return nil
+}
+
+// attachGroupQuotes fills ReplyTo on msgs as seen by viewerID.
+func attachGroupQuotes(ctx context.Context, q sqlx.QueryerContext, msgs []models.GroupMessage, viewerID int) error {
+	ids := make([]int, 0, len(msgs))
+	for _, m := range msgs {
+		if m.ReplyToMessageID != nil {
+			ids = append(ids, *m.ReplyToMessageID)
+		}
+	}
+	quotes, err := loadQuotes(ctx, q, "group_messages", groupQuoteHidden, viewerID, ids)
+	if err != nil {
+		return err
+	}
+	for i := range msgs {
+		if msgs[i].ReplyToMessageID != nil {
+			msgs[i].ReplyTo = quotes[*msgs[i].ReplyToMessageID]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"chat-service/internal/models"
+)
+
+const (
+	reactionScopeChat  = "chat"
+	reactionScopeGroup = "group"
+)
+
+// addReaction stores a reaction and reports whether it was newly added.
+func addReaction(ctx context.Context, db *sqlx.DB, scope string, messageID, userID int, emoji string) (bool, error) {
+	res, err := db.ExecContext(ctx, `INSERT INTO message_reactions (scope, message_id, user_id, emoji) VALUES ($1, $2, $3, $4)
+        ON CONFLICT (scope, message_id, user_id, emoji) DO NOTHING`, scope, messageID, userID, emoji)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	return count > 0, err
+}
+
+// removeReaction deletes a reaction and reports whether one existed.
+func removeReaction(ctx context.Context, db *sqlx.DB, scope string, messageID, userID int, emoji string) (bool, error) {
+	res, err := db.ExecContext(ctx, `DELETE FROM message_reactions WHERE scope=$1 AND message_id=$2 AND user_id=$3 AND emoji=$4`, scope, messageID, userID, emoji)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	return count > 0, err
+}
+
+type reactionRow struct {
+	MessageID int `db:"message_id"`
+	models.ReactionSummary
+}
+
+// loadReactions aggregates reactions per message and emoji, flagging the ones left by viewerID.
+func loadReactions(ctx context.Context, q sqlx.QueryerContext, scope string, messageIDs []int, viewerID int) (map[int][]models.ReactionSummary, error) {
+	result := map[int][]models.ReactionSummary{}
+	if len(messageIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []reactionRow
+	err := sqlx.SelectContext(ctx, q, &rows, `SELECT message_id, emoji, COUNT(*) AS count, BOOL_OR(user_id=$3) AS reacted_by_me
+        FROM message_reactions
+        WHERE scope=$1 AND message_id = ANY($2)
+        GROUP BY message_id, emoji
+        ORDER BY message_id, MIN(created_at), emoji`, scope, pq.Array(messageIDs), viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.MessageID] = append(result[row.MessageID], row.ReactionSummary)
+	}
+	return result, nil
+}
+
+func attachChatReactions(ctx context.Context, q sqlx.QueryerContext, msgs []models.Message, viewerID int) error {
+	ids := make([]int, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+	reactions, err := loadReactions(ctx, q, reactionScopeChat, ids, viewerID)
+	if err != nil {
+		return err
+	}
+	for i := range msgs {
+		msgs[i].Reactions = reactions[msgs[i].ID]
+	}
+	return nil
+}
+
+func attachGroupReactions(ctx context.Context, q sqlx.QueryerContext, msgs []models.GroupMessage, viewerID int) error {
+	ids := make([]int, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+	reactions, err := loadReactions(ctx, q, reactionScopeGroup, ids, viewerID)
+	if err != nil {
+		return err
+	}
+	for i := range msgs {
+		msgs[i].Reactions = reactions[msgs[i].ID]
+	}
+	return nil
+}
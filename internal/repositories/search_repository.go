@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"chat-service/internal/models"
+)
+
+// DefaultSearchLimit is used when a search does not specify a limit.
+const DefaultSearchLimit = 20
+
+// MaxSearchLimit caps the number of results returned in one search page.
+const MaxSearchLimit = 50
+
+// SearchQuery describes a full-text message search. Zero filters are ignored; ChatID and
+// GroupID restrict the search to one conversation and are mutually exclusive.
+type SearchQuery struct {
+	Text     string
+	ChatID   int
+	GroupID  int
+	SenderID int
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+func (q SearchQuery) limit() int {
+	if q.Limit <= 0 {
+		return DefaultSearchLimit
+	}
+	if q.Limit > MaxSearchLimit {
+		return MaxSearchLimit
+	}
+	return q.Limit
+}
+
+// SearchRepository searches messages visible to a user.
+type SearchRepository interface {
+	SearchMessages(ctx context.Context, userID int, query SearchQuery) ([]models.SearchResult, int, error)
+}
+
+// SearchRepo is a sqlx-backed repository using the messages' search_vector columns.
+type SearchRepo struct {
+	db *sqlx.DB
+}
+
+// NewSearchRepo constructs SearchRepo.
+func NewSearchRepo(db *sqlx.DB) *SearchRepo {
+	return &SearchRepo{db: db}
+}
+
+// searchHeadlineOptions configures ts_headline; the text is escaped first, so snippets are safe HTML.
+const searchHeadlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" … "`
+
+// SearchMessages returns one page of chat and group messages matching query.Text, best matches
+// first, applying the same visibility rules as the message lists. Chats the user deleted for
+// themselves, group messages of users they blocked and group system messages are left out. The returned offset points at the next
+// page (0 when exhausted).
+func (r *SearchRepo) SearchMessages(ctx context.Context, userID int, query SearchQuery) ([]models.SearchResult, int, error) {
+	args := []any{userID, query.Text}
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	// filters apply to both tables and are qualified per branch
+	var filters []string
+	if query.SenderID > 0 {
+		filters = append(filters, "sender_id = "+arg(query.SenderID))
+	}
+	if query.From != nil {
+		filters = append(filters, "created_at >= "+arg(*query.From))
+	}
+	if query.To != nil {
+		filters = append(filters, "created_at < "+arg(*query.To))
+	}
+	qualified := func(alias string) string {
+		var b strings.Builder
+		for _, f := range filters {
+			b.WriteString(" AND " + alias + "." + f)
+		}
+		return b.String()
+	}
+
+	var branches []string
+	if query.GroupID == 0 {
+		chatFilter := ""
+		if query.ChatID > 0 {
+			chatFilter = " AND m.chat_id = " + arg(query.ChatID)
+		}
+		branches = append(branches, `SELECT 'chat' AS scope, m.id AS message_id, m.chat_id, NULL::int AS group_id,
+                m.sender_id, m.created_at, m.content, ts_rank(m.search_vector, q.query) AS rank
+            FROM messages m
+            JOIN chats c ON c.id = m.chat_id AND (c.user1_id = $1 OR c.user2_id = $1)
+            LEFT JOIN chat_visibility cv ON cv.chat_id = m.chat_id AND cv.user_id = $1
+            CROSS JOIN q
+            WHERE m.search_vector @@ q.query
+            AND (cv.hidden IS NULL OR cv.hidden = FALSE)
+            AND m.deleted_for_all = FALSE
+            AND NOT (m.sender_id = $1 AND m.deleted_by_sender = TRUE)
+            AND NOT (m.sender_id <> $1 AND m.deleted_by_receiver = TRUE)`+
+			chatFilter+qualified("m"))
+	}
+	if query.ChatID == 0 {
+		groupFilter := ""
+		if query.GroupID > 0 {
+			groupFilter = " AND gm.group_id = " + arg(query.GroupID)
+		}
+		branches = append(branches, `SELECT 'group' AS scope, gm.id AS message_id, NULL::int AS chat_id, gm.group_id,
+                gm.sender_id, gm.created_at, gm.content, ts_rank(gm.search_vector, q.query) AS rank
+            FROM group_messages gm
+            JOIN group_members mem ON mem.group_id = gm.group_id AND mem.user_id = $1
+            CROSS JOIN q
+            WHERE gm.search_vector @@ q.query
+            AND gm.deleted_for_all = FALSE
+            AND gm.kind = '`+models.GroupMessageKindUser+`'
+            AND `+notBlockedSender("gm", 1)+
+			groupFilter+qualified("gm"))
+	}
+
+	limit := query.limit()
+	sqlQuery := `WITH q AS (SELECT websearch_to_tsquery('simple', $2) AS query)
+        SELECT page.scope, page.message_id, page.chat_id, page.group_id, page.sender_id, page.created_at,
+            ts_headline('simple', replace(replace(replace(page.content, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+                q.query, '` + searchHeadlineOptions + `') AS snippet
+        FROM (
+            ` + strings.Join(branches, "\n            UNION ALL\n            ") + `
+            ORDER BY rank DESC, created_at DESC, message_id DESC
+            LIMIT ` + strconv.Itoa(limit+1) + ` OFFSET ` + arg(query.Offset) + `
+        ) page
+        CROSS JOIN q
+        ORDER BY page.rank DESC, page.created_at DESC, page.message_id DESC`
+
+	var results []models.SearchResult
+	if err := r.db.SelectContext(ctx, &results, sqlQuery, args...); err != nil {
+		return nil, 0, err
+	}
+	if len(results) > limit {
+		return results[:limit], query.Offset + limit, nil
+	}
+	return results, 0, nil
+}
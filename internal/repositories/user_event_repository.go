@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+
+	"chat-service/internal/events"
+	"chat-service/internal/models"
+	"chat-service/internal/outbox"
+)
+
+// ErrEventProcessed is returned when a consumed event was already applied.
+var ErrEventProcessed = errors.New("event already processed")
+
+// UserDeletion describes the changes made when a user's account was deleted.
+type UserDeletion struct {
+	// ChatIDs are the user's private chats, now read-only.
+	ChatIDs []int
+	// GroupIDs are the groups the user was removed from.
+	GroupIDs []int
+}
+
+// UserEventRepository applies user-service events. Each event is applied at most once, keyed by
+// its event id; redeliveries return ErrEventProcessed.
+type UserEventRepository interface {
+	RestrictChat(ctx context.Context, eventID string, eventType string, userID int, otherID int, reason string) (int, error)
+	DeleteUserData(ctx context.Context, eventID string, eventType string, userID int) (UserDeletion, error)
+}
+
+// UserEventRepo is a sqlx implementation of UserEventRepository.
+type UserEventRepo struct {
+	db     *sqlx.DB
+	outbox *outbox.Writer
+}
+
+// NewUserEventRepo constructs a UserEventRepo recording domain events in events.
+func NewUserEventRepo(db *sqlx.DB, events *outbox.Writer) *UserEventRepo {
+	return &UserEventRepo{db: db, outbox: events}
+}
+
+// RestrictChat makes the chat between two users read-only for reason and returns its id, or 0
+// when they have no chat. Chats of deleted accounts keep their reason.
+func (r *UserEventRepo) RestrictChat(ctx context.Context, eventID string, eventType string, userID int, otherID int, reason string) (int, error) {
+	participants := []int{userID, otherID}
+	sort.Ints(participants)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = markEventProcessed(ctx, tx, eventID, eventType); err != nil {
+		return 0, err
+	}
+	var chatID int
+	err = tx.GetContext(ctx, &chatID, `UPDATE chats SET read_only_reason=$3
+        WHERE user1_id=$1 AND user2_id=$2 AND read_only_reason IS DISTINCT FROM $4
+        RETURNING id`, participants[0], participants[1], reason, models.ChatReadOnlyAccountDeleted)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return chatID, nil
+}
+
+// DeleteUserData removes a deleted account from chat-service: its private chats become
+// read-only and the messages it sent there are erased, it leaves every group (passing ownership
+// on), its group messages are attributed to models.DeletedUserID, and its reactions, read
+// markers, presence and blocks are dropped.
+func (r *UserEventRepo) DeleteUserData(ctx context.Context, eventID string, eventType string, userID int) (UserDeletion, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return UserDeletion{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = markEventProcessed(ctx, tx, eventID, eventType); err != nil {
+		return UserDeletion{}, err
+	}
+
+	var deletion UserDeletion
+	if err = tx.SelectContext(ctx, &deletion.ChatIDs, `UPDATE chats SET read_only_reason=$2
+        WHERE user1_id=$1 OR user2_id=$1
+        RETURNING id`, userID, models.ChatReadOnlyAccountDeleted); err != nil {
+		return UserDeletion{}, err
+	}
+	sort.Ints(deletion.ChatIDs)
+	if _, err = tx.ExecContext(ctx, `DELETE FROM message_edits WHERE scope=$2 AND message_id IN (SELECT id FROM messages WHERE sender_id=$1)`, userID, editScopeChat); err != nil {
+		return UserDeletion{}, err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE messages SET content='', deleted_for_all=TRUE WHERE sender_id=$1`, userID); err != nil {
+		return UserDeletion{}, err
+	}
+
+	if err = tx.SelectContext(ctx, &deletion.GroupIDs, `SELECT group_id FROM group_members WHERE user_id=$1 ORDER BY group_id`, userID); err != nil {
+		return UserDeletion{}, err
+	}
+	for _, groupID := range deletion.GroupIDs {
+		var newOwnerID int
+		if newOwnerID, err = leaveGroup(ctx, tx, groupID, userID); err != nil {
+			return UserDeletion{}, err
+		}
+		if err = r.outbox.Add(ctx, tx, outbox.Aggregate(events.ScopeGroup, groupID),
+			events.GroupMemberRemoved{GroupID: groupID, UserID: userID, Reason: events.RemovalAccountDeleted, NewOwnerID: newOwnerID}, 0); err != nil {
+			return UserDeletion{}, err
+		}
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE group_messages SET sender_id=$2 WHERE sender_id=$1`, userID, models.DeletedUserID); err != nil {
+		return UserDeletion{}, err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE message_edits SET editor_id=$2 WHERE editor_id=$1`, userID, models.DeletedUserID); err != nil {
+		return UserDeletion{}, err
+	}
+
+	for _, query := range []string{
+		`DELETE FROM message_reactions WHERE user_id=$1`,
+		`DELETE FROM chat_reads WHERE user_id=$1`,
+		`DELETE FROM group_reads WHERE user_id=$1`,
+		`DELETE FROM chat_visibility WHERE user_id=$1`,
+		`DELETE FROM user_presence WHERE user_id=$1`,
+		`DELETE FROM user_blocks WHERE blocker_id=$1 OR blocked_id=$1`,
+	} {
+		if _, err = tx.ExecContext(ctx, query, userID); err != nil {
+			return UserDeletion{}, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return UserDeletion{}, err
+	}
+	return deletion, nil
+}
+
+// markEventProcessed records eventID, or returns ErrEventProcessed when it was already recorded.
+func markEventProcessed(ctx context.Context, tx *sqlx.Tx, eventID string, eventType string) error {
+	res, err := tx.ExecContext(ctx, `INSERT INTO processed_events (event_id, event_type) VALUES ($1, $2) ON CONFLICT (event_id) DO NOTHING`, eventID, eventType)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrEventProcessed
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	ctx := context.Background()
+
+	n, err := store.Put(ctx, "2024/05/blob", strings.NewReader("hello"))
+	if err != nil || n != 5 {
+		t.Fatalf("put: n=%d err=%v", n, err)
+	}
+
+	rc, err := store.Open(ctx, "2024/05/blob")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "hello" {
+		t.Fatalf("unexpected content %q", body)
+	}
+
+	if err := store.Delete(ctx, "2024/05/blob"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Open(ctx, "2024/05/blob"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestLocalStoreRejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../b", "a//b"} {
+		if _, err := store.Put(context.Background(), key, strings.NewReader("x")); err == nil {
+			t.Fatalf("expected key %q to be rejected", key)
+		}
+	}
+}
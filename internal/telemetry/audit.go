@@ -32,6 +32,9 @@ type Envelope struct {
 	Payload       AuditPayload `json:"payload"`
 }
 
+// AuditEnvelope is the envelope carried by audit_log events.
+type AuditEnvelope = Envelope
+
 type AuditPayload struct {
 	Level string `json:"level"`
 	Text  string `json:"text"`
@@ -0,0 +1,82 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DomainEvent is a business fact published for downstream services. Each event type has its own
+// payload schema, versioned independently; fields are only added within a schema version.
+type DomainEvent interface {
+	EventType() string
+	SchemaVersion() int
+}
+
+// EventEnvelope wraps a domain event with the same metadata as audit_log envelopes.
+type EventEnvelope struct {
+	SchemaVersion int         `json:"schema_version"`
+	EventID       string      `json:"event_id"`
+	EventType     string      `json:"event_type"`
+	OccurredAt    string      `json:"occurred_at"`
+	Service       string      `json:"service"`
+	Environment   string      `json:"environment"`
+	RequestID     string      `json:"request_id,omitempty"`
+	UserID        *int64      `json:"user_id,omitempty"`
+	Payload       DomainEvent `json:"payload"`
+}
+
+// EventSource wraps domain events in envelopes, each published under the routing key
+// "<prefix>.<event type>".
+type EventSource struct {
+	prefix      string
+	service     string
+	environment string
+}
+
+func NewEventSource(routingKeyPrefix, service, environment string) *EventSource {
+	return &EventSource{
+		prefix:      routingKeyPrefix,
+		service:     service,
+		environment: environment,
+	}
+}
+
+// NewEnvelope wraps event for publishing on behalf of actorID, which may be zero for system events.
+func (e *EventSource) NewEnvelope(event DomainEvent, requestID string, actorID int) EventEnvelope {
+	envelope := EventEnvelope{
+		SchemaVersion: event.SchemaVersion(),
+		EventID:       uuid.NewString(),
+		EventType:     event.EventType(),
+		OccurredAt:    time.Now().UTC().Format(time.RFC3339Nano),
+		Service:       e.service,
+		Environment:   e.environment,
+		RequestID:     requestID,
+		Payload:       event,
+	}
+	if actorID != 0 {
+		uid := int64(actorID)
+		envelope.UserID = &uid
+	}
+	return envelope
+}
+
+// RoutingKey returns the routing key events of eventType are published under.
+func (e *EventSource) RoutingKey(eventType string) string {
+	return e.prefix + "." + eventType
+}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying requestID, so that domain events recorded
+// further down the call chain can be correlated with the request.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request id stored by ContextWithRequestID, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
@@ -0,0 +1,186 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"chat-service/internal/models"
+)
+
+// relayTimeout bounds publishing one hub operation to the backplane.
+const relayTimeout = 5 * time.Second
+
+// Backplane carries hub operations between service instances, so that an event raised on one
+// instance reaches the sockets held by all of them. Payloads are opaque to the backplane and
+// every payload is handed to every subscribed instance, including the publisher.
+type Backplane interface {
+	Publish(ctx context.Context, payload []byte) error
+	Subscribe(handler func(payload []byte)) error
+}
+
+// Relay operations. Each one is applied locally by the instance that raised it and then
+// replayed by the others from the backplane.
+const (
+	relayChatEvent           = "chat_event"
+	relayChatEphemeral       = "chat_ephemeral"
+	relayGroupEvent          = "group_event"
+	relayGroupEphemeral      = "group_ephemeral"
+	relaySubscribeChat       = "subscribe_chat"
+	relaySubscribeGroup      = "subscribe_group"
+	relayUnsubscribeGroup    = "unsubscribe_group"
+	relayDisconnectGroupUser = "disconnect_group_user"
+	relayStopTyping          = "stop_typing"
+	relayPush                = "push"
+	relayBlock               = "block"
+	relayUnblock             = "unblock"
+	relayUserOnline          = "user_online"
+	relayUserOffline         = "user_offline"
+	relayPresenceSync        = "presence_sync"
+)
+
+// relayMessage is a hub operation on the backplane. UserID is the subject of the operation,
+// or the user to skip for ephemeral events.
+type relayMessage struct {
+	Origin  string          `json:"origin"`
+	Op      string          `json:"op"`
+	ChatID  int             `json:"chat_id,omitempty"`
+	GroupID int             `json:"group_id,omitempty"`
+	UserID  int             `json:"user_id,omitempty"`
+	UserIDs []int           `json:"user_ids,omitempty"`
+	Event   json.RawMessage `json:"event,omitempty"`
+}
+
+// SetBackplane connects the hub to the other instances. Call it before serving connections.
+// Sequence numbers stay per instance, so a client resuming on another instance is told to resync.
+func (h *Hub) SetBackplane(backplane Backplane) error {
+	h.mu.Lock()
+	h.backplane = backplane
+	h.mu.Unlock()
+	return backplane.Subscribe(h.handleRelay)
+}
+
+// relay publishes an operation already applied locally. event, when set, is the event to deliver.
+func (h *Hub) relay(msg relayMessage, event any) {
+	h.mu.RLock()
+	backplane := h.backplane
+	h.mu.RUnlock()
+	if backplane == nil {
+		return
+	}
+
+	msg.Origin = h.instanceID
+	if event != nil {
+		raw, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("backplane encode failed: %v", err)
+			return
+		}
+		msg.Event = raw
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("backplane encode failed: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), relayTimeout)
+	defer cancel()
+	if err := backplane.Publish(ctx, payload); err != nil {
+		log.Printf("backplane publish %s failed: %v", msg.Op, err)
+	}
+}
+
+// handleRelay applies an operation raised by another instance to the local sockets.
+func (h *Hub) handleRelay(payload []byte) {
+	var msg relayMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		log.Printf("backplane decode failed: %v", err)
+		return
+	}
+	if msg.Origin == h.instanceID {
+		return
+	}
+
+	switch msg.Op {
+	case relayChatEvent, relayChatEphemeral:
+		var event models.ChatEvent
+		if err := json.Unmarshal(msg.Event, &event); err != nil {
+			log.Printf("backplane decode %s failed: %v", msg.Op, err)
+			return
+		}
+		event.Seq = 0
+		if msg.Op == relayChatEvent {
+			h.broadcastChatLocal(msg.ChatID, event)
+		} else {
+			h.sendChatLocal(msg.ChatID, event, msg.UserID)
+		}
+	case relayGroupEvent, relayGroupEphemeral:
+		var event models.GroupEvent
+		if err := json.Unmarshal(msg.Event, &event); err != nil {
+			log.Printf("backplane decode %s failed: %v", msg.Op, err)
+			return
+		}
+		event.Seq = 0
+		if msg.Op == relayGroupEvent {
+			h.broadcastGroupLocal(msg.GroupID, event)
+		} else {
+			h.sendGroupLocal(msg.GroupID, event, msg.UserID)
+		}
+	case relaySubscribeChat:
+		h.subscribeChatLocal(msg.ChatID, msg.UserIDs...)
+	case relaySubscribeGroup:
+		h.subscribeGroupLocal(msg.GroupID, msg.UserIDs...)
+	case relayUnsubscribeGroup:
+		h.unsubscribeGroupLocal(msg.GroupID, msg.UserID)
+	case relayDisconnectGroupUser:
+		h.disconnectGroupUserLocal(msg.GroupID, msg.UserID)
+	case relayStopTyping:
+		h.stopTyping(typingKey{group: msg.GroupID != 0, id: msg.ChatID + msg.GroupID, userID: msg.UserID})
+	case relayPush:
+		h.deliverToUsers(msg.UserIDs, msg.Event)
+	case relayBlock, relayUnblock:
+		for _, blockedID := range msg.UserIDs {
+			h.setBlockedLocal(msg.UserID, blockedID, msg.Op == relayBlock)
+		}
+	case relayUserOnline, relayUserOffline:
+		h.setRemotePresence(msg.Origin, []int{msg.UserID}, msg.Op == relayUserOnline, false)
+	case relayPresenceSync:
+		h.setRemotePresence(msg.Origin, msg.UserIDs, true, true)
+	default:
+		log.Printf("backplane: unknown operation %q", msg.Op)
+	}
+}
+
+// MemoryBackplane connects hubs within one process. It serves single-node deployments and tests.
+type MemoryBackplane struct {
+	mu       sync.RWMutex
+	handlers []func(payload []byte)
+}
+
+// NewMemoryBackplane creates an in-process backplane.
+func NewMemoryBackplane() *MemoryBackplane {
+	return &MemoryBackplane{}
+}
+
+// Publish hands payload to every subscriber synchronously.
+func (b *MemoryBackplane) Publish(ctx context.Context, payload []byte) error {
+	b.mu.RLock()
+	handlers := append([]func([]byte){}, b.handlers...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(payload)
+	}
+	return nil
+}
+
+// Subscribe registers a handler for every published payload.
+func (b *MemoryBackplane) Subscribe(handler func(payload []byte)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+	return nil
+}
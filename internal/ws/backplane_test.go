@@ -0,0 +1,100 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"chat-service/internal/models"
+)
+
+// newHubPair returns two hubs connected like two service instances.
+func newHubPair(t *testing.T) (*Hub, *Hub) {
+	t.Helper()
+
+	backplane := NewMemoryBackplane()
+	a, b := NewHub(), NewHub()
+	for _, hub := range []*Hub{a, b} {
+		if err := hub.SetBackplane(backplane); err != nil {
+			t.Fatalf("subscribe failed: %v", err)
+		}
+	}
+	return a, b
+}
+
+func TestBackplaneDeliversRoomEventsAcrossHubs(t *testing.T) {
+	a, b := newHubPair(t)
+	localServer, localClient := newTestConnPair(t)
+	remoteServer, remoteClient := newTestConnPair(t)
+	a.AddChatClient(1, 10, localServer)
+	b.AddChatClient(1, 11, remoteServer)
+
+	a.BroadcastChatMessage(1, models.Message{ID: 5, ChatID: 1})
+	if event := readEvent(t, localClient); event["type"] != "message" {
+		t.Fatalf("expected local message event, got %v", event)
+	}
+	event := readEvent(t, remoteClient)
+	if event["type"] != "message" || event["chat_id"] != float64(1) {
+		t.Fatalf("expected relayed message event, got %v", event)
+	}
+	if event["seq"] != float64(b.chatLogs[1].seq) {
+		t.Fatalf("expected relayed event to carry the receiving hub's seq, got %v", event)
+	}
+
+	a.StartChatTyping(1, 10)
+	if event := readEvent(t, remoteClient); event["type"] != "typing_started" {
+		t.Fatalf("expected relayed typing event, got %v", event)
+	}
+	expectNoEvent(t, localClient)
+}
+
+func TestBackplaneRelaysMembershipChanges(t *testing.T) {
+	a, b := newHubPair(t)
+	muxServer, muxClient := newTestConnPair(t)
+	roomServer, _ := newTestConnPair(t)
+	b.AddUserClient(10, muxServer, nil, nil)
+	b.AddGroupClient(2, 10, roomServer)
+
+	a.SubscribeGroup(2, 10)
+	if event := readEvent(t, muxClient); event["type"] != "group_joined" {
+		t.Fatalf("expected relayed group_joined, got %v", event)
+	}
+
+	a.DisconnectGroupUser(2, 10)
+	if event := readEvent(t, muxClient); event["type"] != "group_left" {
+		t.Fatalf("expected relayed group_left, got %v", event)
+	}
+	if len(b.groupRooms[2]) != 0 {
+		t.Fatalf("expected remote group sockets to be closed")
+	}
+}
+
+func TestBackplaneRelaysPresence(t *testing.T) {
+	a, b := newHubPair(t)
+
+	client := a.AddChatClient(1, 10, nil)
+	if !b.Online(10) || !b.OnlineElsewhere(10) {
+		t.Fatalf("expected user connected to the other instance to be online")
+	}
+	if a.OnlineElsewhere(10) {
+		t.Fatalf("expected local connections not to count as elsewhere")
+	}
+
+	a.RemoveChatClient(1, client)
+	if b.Online(10) {
+		t.Fatalf("expected user to go offline with their last connection")
+	}
+}
+
+func TestPresenceSyncExpiresSilentInstances(t *testing.T) {
+	hub := NewHub()
+	hub.remoteTTL = 10 * time.Millisecond
+	hub.setRemotePresence("crashed", []int{10}, true, true)
+	if !hub.Online(10) {
+		t.Fatalf("expected synced user to be online")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if hub.Online(10) {
+		t.Fatalf("expected users of a silent instance to stop counting")
+	}
+}
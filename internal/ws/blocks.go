@@ -0,0 +1,190 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"chat-service/internal/models"
+)
+
+// blockLoadTimeout bounds loading a user's block list when they connect.
+const blockLoadTimeout = 2 * time.Second
+
+// BlockSource lists the users a user has blocked. Their messages and typing indicators are not
+// delivered to the blocker, in chats as well as groups.
+type BlockSource interface {
+	BlockedUsers(ctx context.Context, userID int) ([]int, error)
+}
+
+// SetBlockSource registers where block lists are loaded from. Call it before serving connections.
+func (h *Hub) SetBlockSource(source BlockSource) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.blockSource = source
+}
+
+// Block stops delivering blockedID's messages to blockerID's connections on every instance.
+func (h *Hub) Block(blockerID int, blockedID int) {
+	h.setBlockedLocal(blockerID, blockedID, true)
+	h.relay(relayMessage{Op: relayBlock, UserID: blockerID, UserIDs: []int{blockedID}}, nil)
+}
+
+// Unblock resumes delivering blockedID's messages to blockerID on every instance.
+func (h *Hub) Unblock(blockerID int, blockedID int) {
+	h.setBlockedLocal(blockerID, blockedID, false)
+	h.relay(relayMessage{Op: relayUnblock, UserID: blockerID, UserIDs: []int{blockedID}}, nil)
+}
+
+// blockLoad tracks the connections of a user that are being registered. While any is pending,
+// the user's block list is kept, and ids unblocked meanwhile are remembered so that a list
+// fetched before the unblock does not bring them back.
+type blockLoad struct {
+	pending   int
+	unblocked map[int]struct{}
+}
+
+// setBlockedLocal updates the block list of a connected or connecting user. Other users load
+// their list when they connect.
+func (h *Hub) setBlockedLocal(blockerID int, blockedID int, blocked bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	load, loading := h.blockLoads[blockerID]
+	if h.connections[blockerID] == 0 && !loading {
+		return
+	}
+	if !blocked {
+		delete(h.blocks[blockerID], blockedID)
+		if len(h.blocks[blockerID]) == 0 {
+			delete(h.blocks, blockerID)
+		}
+		if loading {
+			if load.unblocked == nil {
+				load.unblocked = make(map[int]struct{})
+			}
+			load.unblocked[blockedID] = struct{}{}
+		}
+		return
+	}
+	if loading {
+		delete(load.unblocked, blockedID)
+	}
+	if _, ok := h.blocks[blockerID]; !ok {
+		h.blocks[blockerID] = make(map[int]struct{})
+	}
+	h.blocks[blockerID][blockedID] = struct{}{}
+}
+
+// loadBlocks prepares a connection of userID for registration, fetching the user's block list
+// when they hold no connection yet; later connections share the list Block and Unblock keep up
+// to date. Every call must be followed by storeBlocksLocked. The result is nil when nothing was
+// fetched: the user is already connected, no source is set, or the fetch failed (logged).
+func (h *Hub) loadBlocks(userID int) map[int]struct{} {
+	h.mu.Lock()
+	load, ok := h.blockLoads[userID]
+	if !ok {
+		load = &blockLoad{}
+		h.blockLoads[userID] = load
+	}
+	load.pending++
+	source := h.blockSource
+	first := h.connections[userID] == 0
+	h.mu.Unlock()
+	if source == nil || !first {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), blockLoadTimeout)
+	defer cancel()
+	blockedIDs, err := source.BlockedUsers(ctx, userID)
+	if err != nil {
+		log.Printf("ws: load blocks for user %d failed: %v", userID, err)
+		return nil
+	}
+	blocked := make(map[int]struct{}, len(blockedIDs))
+	for _, id := range blockedIDs {
+		blocked[id] = struct{}{}
+	}
+	return blocked
+}
+
+// storeBlocksLocked merges a list fetched by loadBlocks into the user's block list and ends the
+// load. A nil list keeps the current one. h.mu must be held.
+func (h *Hub) storeBlocksLocked(userID int, blocked map[int]struct{}) {
+	load := h.blockLoads[userID]
+	for id := range blocked {
+		if _, ok := load.unblocked[id]; ok {
+			continue
+		}
+		if _, ok := h.blocks[userID]; !ok {
+			h.blocks[userID] = make(map[int]struct{})
+		}
+		h.blocks[userID][id] = struct{}{}
+	}
+	load.pending--
+	if load.pending == 0 {
+		delete(h.blockLoads, userID)
+	}
+}
+
+// blockedLocked reports whether recipientID blocked senderID. h.mu must be held.
+func (h *Hub) blockedLocked(recipientID int, senderID int) bool {
+	if senderID == 0 {
+		return false
+	}
+	_, ok := h.blocks[recipientID][senderID]
+	return ok
+}
+
+// withoutBlockers drops the clients and users that blocked senderID.
+func (h *Hub) withoutBlockers(clients []*Client, userIDs []int, senderID int) ([]*Client, []int) {
+	if senderID == 0 {
+		return clients, userIDs
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if len(h.blocks) == 0 {
+		return clients, userIDs
+	}
+	keptClients := clients[:0]
+	for _, client := range clients {
+		if !h.blockedLocked(client.userID, senderID) {
+			keptClients = append(keptClients, client)
+		}
+	}
+	keptUsers := userIDs[:0]
+	for _, userID := range userIDs {
+		if !h.blockedLocked(userID, senderID) {
+			keptUsers = append(keptUsers, userID)
+		}
+	}
+	return keptClients, keptUsers
+}
+
+// groupEventSender returns the user whose content a group event carries, or 0 for events that
+// are delivered regardless of blocks.
+func groupEventSender(event models.GroupEvent) int {
+	switch event.Type {
+	case "message", "edit":
+		if event.Message != nil && event.Message.Kind != models.GroupMessageKindSystem {
+			return event.Message.SenderID
+		}
+	case "typing_started", "typing_stopped":
+		return event.UserID
+	}
+	return 0
+}
+
+// chatEventSender returns the user whose content a chat event carries, or 0 for events that
+// are delivered regardless of blocks.
+func chatEventSender(event models.ChatEvent) int {
+	switch event.Type {
+	case "message", "edit":
+		if event.Message != nil {
+			return event.Message.SenderID
+		}
+	case "typing_started", "typing_stopped":
+		return event.UserID
+	}
+	return 0
+}
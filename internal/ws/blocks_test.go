@@ -0,0 +1,209 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"chat-service/internal/models"
+)
+
+// staticBlocks is a BlockSource backed by a fixed map of blocker to blocked users.
+type staticBlocks map[int][]int
+
+func (s staticBlocks) BlockedUsers(ctx context.Context, userID int) ([]int, error) {
+	return s[userID], nil
+}
+
+// blockSourceFunc adapts a function to BlockSource.
+type blockSourceFunc func(ctx context.Context, userID int) ([]int, error)
+
+func (f blockSourceFunc) BlockedUsers(ctx context.Context, userID int) ([]int, error) {
+	return f(ctx, userID)
+}
+
+func TestHubHidesBlockedSenderGroupMessages(t *testing.T) {
+	hub := NewHub()
+	hub.SetBlockSource(staticBlocks{10: {11}})
+	server, client := newTestConnPair(t)
+	hub.AddGroupClient(2, 10, server)
+
+	hub.BroadcastGroupMessage(2, models.GroupMessage{ID: 1, GroupID: 2, SenderID: 11})
+	hub.BroadcastGroupMessage(2, models.GroupMessage{ID: 2, GroupID: 2, SenderID: 12})
+
+	event := readEvent(t, client)
+	message, _ := event["message"].(map[string]any)
+	if event["type"] != "message" || message["id"] != float64(2) {
+		t.Fatalf("expected only the unblocked sender's message, got %v", event)
+	}
+}
+
+func TestHubDeliversSystemMessagesOfBlockedUsers(t *testing.T) {
+	hub := NewHub()
+	hub.SetBlockSource(staticBlocks{10: {11}})
+	server, client := newTestConnPair(t)
+	hub.AddUserClient(10, server, nil, []int{2})
+
+	hub.BroadcastGroupMessage(2, models.GroupMessage{ID: 1, GroupID: 2, SenderID: 11, Kind: models.GroupMessageKindSystem})
+
+	if event := readEvent(t, client); event["type"] != "message" {
+		t.Fatalf("expected system message, got %v", event)
+	}
+}
+
+func TestHubBlockAndUnblockApplyLive(t *testing.T) {
+	hub := NewHub()
+	server, client := newTestConnPair(t)
+	hub.AddUserClient(10, server, nil, []int{2})
+
+	hub.Block(10, 11)
+	hub.BroadcastGroupMessage(2, models.GroupMessage{ID: 1, GroupID: 2, SenderID: 11})
+	hub.Unblock(10, 11)
+	hub.BroadcastGroupMessage(2, models.GroupMessage{ID: 2, GroupID: 2, SenderID: 11})
+
+	event := readEvent(t, client)
+	message, _ := event["message"].(map[string]any)
+	if message["id"] != float64(2) {
+		t.Fatalf("expected the message sent after unblocking, got %v", event)
+	}
+}
+
+func TestHubReplaySkipsBlockedSenders(t *testing.T) {
+	hub := NewHub()
+	hub.SetBlockSource(staticBlocks{10: {11}})
+	hub.BroadcastGroupMessage(2, models.GroupMessage{ID: 1, GroupID: 2, SenderID: 11})
+	hub.BroadcastGroupMessage(2, models.GroupMessage{ID: 2, GroupID: 2, SenderID: 12})
+
+	server, client := newTestConnPair(t)
+	hub.AddGroupClientSince(2, 10, server, hub.seqBase)
+
+	event := readEvent(t, client)
+	message, _ := event["message"].(map[string]any)
+	if message["id"] != float64(2) {
+		t.Fatalf("expected replay without the blocked sender's message, got %v", event)
+	}
+}
+
+func TestHubDropsBlocksWithLastConnection(t *testing.T) {
+	hub := NewHub()
+	hub.SetBlockSource(staticBlocks{10: {11}})
+	client := hub.AddGroupClient(2, 10, nil)
+	if !hub.blockedLocked(10, 11) {
+		t.Fatalf("expected block list to be loaded on connect")
+	}
+
+	hub.RemoveGroupClient(2, client)
+	if len(hub.blocks) != 0 {
+		t.Fatalf("expected block list to be dropped with the last connection")
+	}
+}
+
+func TestHubHidesBlockedSenderChatMessages(t *testing.T) {
+	hub := NewHub()
+	hub.SetBlockSource(staticBlocks{10: {11}})
+	server, client := newTestConnPair(t)
+	hub.AddUserClient(10, server, []int{1}, nil)
+
+	hub.BroadcastChatEdit(1, models.Message{ID: 1, ChatID: 1, SenderID: 11})
+	hub.BroadcastChatMessage(1, models.Message{ID: 2, ChatID: 1, SenderID: 10})
+
+	event := readEvent(t, client)
+	message, _ := event["message"].(map[string]any)
+	if event["type"] != "message" || message["id"] != float64(2) {
+		t.Fatalf("expected only the unblocked sender's message, got %v", event)
+	}
+}
+
+func TestHubHidesBlockedUserChatTyping(t *testing.T) {
+	hub := NewHub()
+	hub.SetBlockSource(staticBlocks{10: {11}})
+	server, client := newTestConnPair(t)
+	hub.AddChatClient(1, 10, server)
+
+	d := &dispatcher{hub: hub, chats: &fakeChatActions{}, chatID: 1}
+	sender := newClient(nil, 11, DefaultHubConfig())
+	if reply := d.handle(sender, []byte(`{"id":"t1","type":"typing_started"}`)); reply.Type != "ack" {
+		t.Fatalf("expected ack, got %+v", reply)
+	}
+	expectNoEvent(t, client)
+}
+
+func TestHubLoadsBlocksOnFirstConnectionOnly(t *testing.T) {
+	hub := NewHub()
+	calls := 0
+	hub.SetBlockSource(blockSourceFunc(func(ctx context.Context, userID int) ([]int, error) {
+		calls++
+		if calls > 1 {
+			return nil, errors.New("unavailable")
+		}
+		return []int{11}, nil
+	}))
+
+	hub.AddGroupClient(2, 10, nil)
+	hub.AddUserClient(10, nil, nil, []int{2})
+
+	if calls != 1 {
+		t.Fatalf("expected one load, got %d", calls)
+	}
+	if !hub.blockedLocked(10, 11) {
+		t.Fatalf("expected block list to be kept for the second connection")
+	}
+}
+
+func TestHubKeepsBlockChangesMadeDuringLoad(t *testing.T) {
+	hub := NewHub()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	hub.SetBlockSource(blockSourceFunc(func(ctx context.Context, userID int) ([]int, error) {
+		close(started)
+		<-release
+		// read before the changes below were committed
+		return []int{11}, nil
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		hub.AddGroupClient(2, 10, nil)
+		close(done)
+	}()
+	<-started
+	hub.Unblock(10, 11)
+	hub.Block(10, 12)
+	close(release)
+	<-done
+
+	if hub.blockedLocked(10, 11) {
+		t.Fatalf("expected the unblock made during the load to stick")
+	}
+	if !hub.blockedLocked(10, 12) {
+		t.Fatalf("expected the block made during the load to stick")
+	}
+	if len(hub.blockLoads) != 0 {
+		t.Fatalf("expected no pending loads, got %v", hub.blockLoads)
+	}
+}
+
+func TestHubKeepsBlocksWhenLoadFails(t *testing.T) {
+	hub := NewHub()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	hub.SetBlockSource(blockSourceFunc(func(ctx context.Context, userID int) ([]int, error) {
+		close(started)
+		<-release
+		return nil, errors.New("unavailable")
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		hub.AddGroupClient(2, 10, nil)
+		close(done)
+	}()
+	<-started
+	hub.Block(10, 11)
+	close(release)
+	<-done
+
+	if !hub.blockedLocked(10, 11) {
+		t.Fatalf("expected the failed load to keep the existing list")
+	}
+}
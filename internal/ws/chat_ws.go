@@ -19,6 +19,7 @@ type ChatWebSocketHandler struct {
 	hub        *Hub
 	chatRepo   repositories.ChatRepository
 	authClient *grpcclient.AuthClient
+	actions    ChatActions
 }
 
 // NewChatWebSocketHandler constructs a ChatWebSocketHandler.
@@ -26,6 +27,11 @@ func NewChatWebSocketHandler(hub *Hub, chatRepo repositories.ChatRepository, aut
 	return &ChatWebSocketHandler{hub: hub, chatRepo: chatRepo, authClient: authClient}
 }
 
+// SetActions enables client requests (see ChatActions) on chat sockets.
+func (h *ChatWebSocketHandler) SetActions(actions ChatActions) {
+	h.actions = actions
+}
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
@@ -37,6 +43,11 @@ func (h *ChatWebSocketHandler) Handle(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
 		return
 	}
+	sinceSeq, ok := sinceSeqQuery(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since_seq"})
+		return
+	}
 
 	token := c.GetHeader("Authorization")
 	if token == "" {
@@ -62,19 +73,21 @@ func (h *ChatWebSocketHandler) Handle(c *gin.Context) {
 	if err != nil {
 		return
 	}
-	h.hub.AddChatClient(chatID, conn)
+	var client *Client
+	if sinceSeq != nil {
+		client = h.hub.AddChatClientSince(chatID, userID, conn, *sinceSeq)
+	} else {
+		client = h.hub.AddChatClient(chatID, userID, conn)
+	}
 
-	// Keep connection alive and clean on close
+	// Serve client requests until the connection closes, then clean up
 	go func() {
 		defer func() {
-			h.hub.RemoveChatClient(chatID, conn)
+			h.hub.RemoveChatClient(chatID, client)
 			conn.Close()
 		}()
-		for {
-			if _, _, err := conn.ReadMessage(); err != nil {
-				return
-			}
-		}
+		d := &dispatcher{hub: h.hub, chats: h.actions, chatID: chatID}
+		d.serve(client)
 	}()
 }
 
@@ -0,0 +1,129 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	errClientClosed  = errors.New("websocket client closed")
+	errSendQueueFull = errors.New("websocket send queue full")
+)
+
+// Client is a websocket connection registered with the hub. Outgoing frames are queued and
+// written by the client's own write pump, so a slow connection never blocks a broadcaster.
+type Client struct {
+	conn   *websocket.Conn
+	userID int
+	cfg    HubConfig
+
+	send    chan []byte
+	done    chan struct{}
+	once    sync.Once
+	evicted atomic.Bool
+}
+
+func newClient(conn *websocket.Conn, userID int, cfg HubConfig) *Client {
+	return &Client{
+		conn:   conn,
+		userID: userID,
+		cfg:    cfg,
+		send:   make(chan []byte, cfg.SendQueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// UserID returns the id of the authenticated user owning the connection.
+func (c *Client) UserID() int {
+	return c.userID
+}
+
+// write queues payload without blocking. When the queue is full the hub's slow-consumer
+// policy applies: the payload is dropped or the connection is evicted.
+func (c *Client) write(payload []byte) error {
+	select {
+	case <-c.done:
+		return errClientClosed
+	default:
+	}
+
+	select {
+	case c.send <- payload:
+		return nil
+	default:
+	}
+
+	if c.cfg.SlowConsumer == DropEvents {
+		log.Printf("websocket send queue full for user %d, dropping frame", c.userID)
+		return errSendQueueFull
+	}
+	log.Printf("websocket send queue full for user %d, evicting connection", c.userID)
+	c.evicted.Store(true)
+	c.close()
+	return errSendQueueFull
+}
+
+func (c *Client) writeJSON(v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.write(payload)
+}
+
+// room returns how many more frames fit in the send queue.
+func (c *Client) room() int {
+	return cap(c.send) - len(c.send)
+}
+
+// close stops the write pump. Frames still queued are discarded.
+func (c *Client) close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+// writePump writes queued frames and pings the peer until the client is closed or a write
+// fails. Failing or evicted connections are closed so that their read loop returns.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(c.cfg.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case payload := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(c.cfg.WriteTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				log.Printf("websocket write error: %v", err)
+				c.conn.Close()
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.cfg.WriteTimeout)); err != nil {
+				c.conn.Close()
+				c.close()
+				return
+			}
+		case <-c.done:
+			if c.evicted.Load() {
+				closeMsg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "slow consumer")
+				_ = c.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(c.cfg.WriteTimeout))
+				c.conn.Close()
+			}
+			return
+		}
+	}
+}
+
+// keepAlive drops the connection when no pong (or other frame) arrives within PongTimeout.
+func (c *Client) keepAlive() {
+	c.conn.SetReadDeadline(time.Now().Add(c.cfg.PongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.cfg.PongTimeout))
+	})
+}
@@ -0,0 +1,74 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientEvictsSlowConsumer(t *testing.T) {
+	server, conn := newTestConnPair(t)
+	cfg := DefaultHubConfig()
+	cfg.SendQueueSize = 1
+	client := newClient(server, 10, cfg)
+
+	if err := client.write([]byte(`{"type":"a"}`)); err != nil {
+		t.Fatalf("expected first frame to be queued, got %v", err)
+	}
+	if err := client.write([]byte(`{"type":"b"}`)); err != errSendQueueFull {
+		t.Fatalf("expected full queue, got %v", err)
+	}
+	if err := client.write([]byte(`{"type":"c"}`)); err != errClientClosed {
+		t.Fatalf("expected evicted client to reject writes, got %v", err)
+	}
+
+	go client.writePump()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+				t.Fatalf("expected try-again-later close, got %v", err)
+			}
+			return
+		}
+	}
+}
+
+func TestClientDropsFramesWhenConfigured(t *testing.T) {
+	cfg := DefaultHubConfig()
+	cfg.SendQueueSize = 1
+	cfg.SlowConsumer = DropEvents
+	client := newClient(nil, 10, cfg)
+
+	client.write([]byte(`{"type":"a"}`))
+	if err := client.write([]byte(`{"type":"b"}`)); err != errSendQueueFull {
+		t.Fatalf("expected full queue, got %v", err)
+	}
+	if err := client.write([]byte(`{"type":"c"}`)); err != errSendQueueFull {
+		t.Fatalf("expected client to stay open, got %v", err)
+	}
+}
+
+func TestClientPingsPeer(t *testing.T) {
+	server, conn := newTestConnPair(t)
+	hub := NewHubWithConfig(HubConfig{PingInterval: 10 * time.Millisecond})
+	client := hub.AddChatClient(1, 10, server)
+	t.Cleanup(func() { hub.RemoveChatClient(1, client) })
+
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	go conn.ReadMessage()
+
+	select {
+	case <-pinged:
+	case <-time.After(time.Second):
+		t.Fatalf("expected a ping")
+	}
+}
@@ -0,0 +1,76 @@
+package ws
+
+import (
+	"fmt"
+	"time"
+)
+
+// SlowConsumerPolicy decides what happens when a client's send queue is full.
+type SlowConsumerPolicy int
+
+const (
+	// EvictSlowConsumer closes the connection with status 1013. The client reconnects and
+	// resumes from its last seq.
+	EvictSlowConsumer SlowConsumerPolicy = iota
+	// DropEvents discards frames that do not fit and keeps the connection open.
+	DropEvents
+)
+
+// ParseSlowConsumerPolicy parses "evict" or "drop".
+func ParseSlowConsumerPolicy(raw string) (SlowConsumerPolicy, error) {
+	switch raw {
+	case "evict":
+		return EvictSlowConsumer, nil
+	case "drop":
+		return DropEvents, nil
+	}
+	return 0, fmt.Errorf("unknown slow consumer policy %q", raw)
+}
+
+// HubConfig tunes connection handling. Zero fields take the DefaultHubConfig value.
+type HubConfig struct {
+	// SendQueueSize is the number of frames buffered per connection.
+	SendQueueSize int
+	// WriteTimeout bounds every frame write.
+	WriteTimeout time.Duration
+	// PingInterval is how often connections are pinged; it must be shorter than PongTimeout.
+	PingInterval time.Duration
+	// PongTimeout is how long a connection may stay silent before it is dropped.
+	PongTimeout time.Duration
+	// SlowConsumer applies when a send queue is full.
+	SlowConsumer SlowConsumerPolicy
+	// EventLogSize is the number of events kept per room for replay.
+	EventLogSize int
+}
+
+// DefaultHubConfig returns the configuration used by NewHub.
+func DefaultHubConfig() HubConfig {
+	return HubConfig{
+		SendQueueSize: 256,
+		WriteTimeout:  10 * time.Second,
+		PingInterval:  30 * time.Second,
+		PongTimeout:   60 * time.Second,
+		SlowConsumer:  EvictSlowConsumer,
+		EventLogSize:  DefaultEventLogSize,
+	}
+}
+
+func (cfg HubConfig) withDefaults() HubConfig {
+	defaults := DefaultHubConfig()
+	if cfg.SendQueueSize <= 0 {
+		cfg.SendQueueSize = defaults.SendQueueSize
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = defaults.WriteTimeout
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = defaults.PingInterval
+	}
+	if cfg.PongTimeout <= 0 {
+		cfg.PongTimeout = defaults.PongTimeout
+	}
+	if cfg.EventLogSize <= 0 {
+		cfg.EventLogSize = defaults.EventLogSize
+	}
+	return cfg
+}
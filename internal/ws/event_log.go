@@ -0,0 +1,173 @@
+package ws
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultEventLogSize is the number of recent events kept per room for replay.
+const DefaultEventLogSize = 256
+
+// roomLog is a ring buffer of a room's most recent events. Sequence numbers start above the
+// hub's base, which is derived from its start time, so cursors issued before a restart never
+// match events issued after it.
+type roomLog struct {
+	seq    int64
+	events []loggedEvent
+	seqs   []int64
+	oldest int
+	count  int
+}
+
+// loggedEvent is a recorded payload and the user whose content it carries (0 for none).
+type loggedEvent struct {
+	payload []byte
+	sender  int
+}
+
+func newRoomLog(base int64, size int) *roomLog {
+	return &roomLog{seq: base, events: make([]loggedEvent, size), seqs: make([]int64, size)}
+}
+
+// next reserves the following sequence number.
+func (l *roomLog) next() int64 {
+	l.seq++
+	return l.seq
+}
+
+func (l *roomLog) append(seq int64, payload []byte, sender int) {
+	size := len(l.events)
+	if size == 0 {
+		return
+	}
+	idx := (l.oldest + l.count) % size
+	if l.count == size {
+		l.oldest = (l.oldest + 1) % size
+	} else {
+		l.count++
+	}
+	l.events[idx] = loggedEvent{payload: payload, sender: sender}
+	l.seqs[idx] = seq
+}
+
+// since returns the events after seq, or false when some of them are no longer available.
+func (l *roomLog) since(seq int64) ([]loggedEvent, bool) {
+	if seq > l.seq {
+		return nil, false
+	}
+	first := l.seq + 1
+	if l.count > 0 {
+		first = l.seqs[l.oldest]
+	}
+	if seq+1 < first {
+		return nil, false
+	}
+	var replay []loggedEvent
+	for i := 0; i < l.count; i++ {
+		idx := (l.oldest + i) % len(l.events)
+		if l.seqs[idx] > seq {
+			replay = append(replay, l.events[idx])
+		}
+	}
+	return replay, true
+}
+
+// resyncEvent tells a client that events were lost and history must be refetched over REST.
+// Seq is the room's current sequence number, from which the client can resume.
+type resyncEvent struct {
+	Type    string `json:"type"`
+	ChatID  int    `json:"chat_id,omitempty"`
+	GroupID int    `json:"group_id,omitempty"`
+	Seq     int64  `json:"seq"`
+}
+
+// roomKey identifies a chat or group room.
+type roomKey struct {
+	group bool
+	id    int
+}
+
+// logLocked returns the room's event log, creating it on first use. h.mu must be held for writing.
+func (h *Hub) logLocked(room roomKey) *roomLog {
+	logs := h.chatLogs
+	if room.group {
+		logs = h.groupLogs
+	}
+	l, ok := logs[room.id]
+	if !ok {
+		l = newRoomLog(h.seqBase, h.cfg.EventLogSize)
+		logs[room.id] = l
+	}
+	return l
+}
+
+// replayPayloadsLocked returns what to send userID resuming from sinceSeq: the missed events,
+// except those of users they blocked, or a single resync_required event when they are gone or
+// exceed limit. h.mu must be held for writing.
+func (h *Hub) replayPayloadsLocked(room roomKey, sinceSeq int64, limit int, userID int) [][]byte {
+	l := h.logLocked(room)
+	if events, ok := l.since(sinceSeq); ok {
+		replay := make([][]byte, 0, len(events))
+		for _, event := range events {
+			if !h.blockedLocked(userID, event.sender) {
+				replay = append(replay, event.payload)
+			}
+		}
+		if len(replay) <= limit {
+			return replay
+		}
+	}
+	event := resyncEvent{Type: "resync_required", Seq: l.seq}
+	if room.group {
+		event.GroupID = room.id
+	} else {
+		event.ChatID = room.id
+	}
+	payload, _ := json.Marshal(event)
+	return [][]byte{payload}
+}
+
+// AddChatClientSince registers a chat connection and first replays the events after sinceSeq.
+// No live event can be interleaved with or lost before the replay.
+func (h *Hub) AddChatClientSince(chatID int, userID int, conn *websocket.Conn, sinceSeq int64) *Client {
+	return h.addRoomClient(roomKey{id: chatID}, h.startClient(conn, userID), &sinceSeq)
+}
+
+// AddGroupClientSince registers a group connection and first replays the events after sinceSeq.
+func (h *Hub) AddGroupClientSince(groupID int, userID int, conn *websocket.Conn, sinceSeq int64) *Client {
+	return h.addRoomClient(roomKey{group: true, id: groupID}, h.startClient(conn, userID), &sinceSeq)
+}
+
+// resume queues a room's events after sinceSeq for an already registered client. Live events
+// delivered meanwhile may be repeated; clients drop events whose seq they have already seen.
+func (h *Hub) resume(client *Client, room roomKey, sinceSeq int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, payload := range h.replayPayloadsLocked(room, sinceSeq, client.room(), client.userID) {
+		if err := client.write(payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hubSeqBase() int64 {
+	return time.Now().UnixMicro()
+}
+
+// sinceSeqQuery parses the optional since_seq query parameter, returning false when it is malformed.
+func sinceSeqQuery(c *gin.Context) (*int64, bool) {
+	raw, ok := c.GetQuery("since_seq")
+	if !ok {
+		return nil, true
+	}
+	seq, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || seq < 0 {
+		return nil, false
+	}
+	return &seq, true
+}
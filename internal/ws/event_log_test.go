@@ -0,0 +1,91 @@
+package ws
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"chat-service/internal/models"
+)
+
+func TestHubReplaysMissedChatEvents(t *testing.T) {
+	hub := NewHub()
+
+	hub.BroadcastChatMessage(1, models.Message{ID: 5, ChatID: 1})
+	seq := hub.chatLogs[1].seq
+	hub.BroadcastChatMessage(1, models.Message{ID: 6, ChatID: 1})
+	hub.BroadcastDeletion(1, 5)
+
+	server, client := newTestConnPair(t)
+	hub.AddChatClientSince(1, 10, server, seq)
+
+	first := readEvent(t, client)
+	if first["type"] != "message" || first["seq"] != float64(seq+1) {
+		t.Fatalf("expected replayed message with seq %d, got %v", seq+1, first)
+	}
+	if second := readEvent(t, client); second["type"] != "delete_for_all" || second["seq"] != float64(seq+2) {
+		t.Fatalf("expected replayed deletion, got %v", second)
+	}
+
+	hub.BroadcastChatRead(1, 11, 6)
+	if live := readEvent(t, client); live["type"] != "read" || live["seq"] != float64(seq+3) {
+		t.Fatalf("expected live event after replay, got %v", live)
+	}
+}
+
+func TestHubRequestsResyncWhenGapTooLarge(t *testing.T) {
+	hub := NewHub()
+	hub.cfg.EventLogSize = 2
+
+	hub.BroadcastGroupDeletion(2, 1)
+	seq := hub.groupLogs[2].seq
+	for i := 2; i <= 4; i++ {
+		hub.BroadcastGroupDeletion(2, i)
+	}
+
+	server, client := newTestConnPair(t)
+	hub.AddGroupClientSince(2, 10, server, seq)
+	event := readEvent(t, client)
+	if event["type"] != "resync_required" || event["seq"] != float64(seq+3) {
+		t.Fatalf("expected resync_required at seq %d, got %v", seq+3, event)
+	}
+
+	// cursors from another hub, e.g. before a restart, are never replayed
+	server, client = newTestConnPair(t)
+	hub.AddGroupClientSince(2, 10, server, 42)
+	if event := readEvent(t, client); event["type"] != "resync_required" {
+		t.Fatalf("expected resync_required for a foreign cursor, got %v", event)
+	}
+}
+
+func TestTypingEventsAreNotSequenced(t *testing.T) {
+	hub := NewHub()
+
+	hub.StartChatTyping(1, 10)
+	if _, ok := hub.chatLogs[1]; ok {
+		t.Fatalf("expected typing events to stay out of the replay log")
+	}
+}
+
+func TestDispatcherResumeReplaysBeforeAck(t *testing.T) {
+	hub := NewHub()
+	hub.BroadcastChatMessage(1, models.Message{ID: 5, ChatID: 1})
+	seq := hub.chatLogs[1].seq
+	hub.BroadcastChatMessage(1, models.Message{ID: 6, ChatID: 1})
+
+	server, client := newTestConnPair(t)
+	c := hub.AddUserClient(10, server, []int{1}, nil)
+	d := &dispatcher{hub: hub}
+
+	frame := []byte(`{"id":"r1","type":"resume","chat_id":1,"since_seq":` + strconv.FormatInt(seq, 10) + `}`)
+	if reply := d.handle(c, frame); reply.Type != "ack" {
+		t.Fatalf("expected resume ack, got %+v", reply)
+	}
+	if event := readEvent(t, client); event["seq"] != float64(seq+1) {
+		t.Fatalf("expected replayed event, got %v", event)
+	}
+
+	if reply := d.handle(c, []byte(`{"id":"r2","type":"resume","chat_id":2,"since_seq":0}`)); reply.Code != http.StatusForbidden {
+		t.Fatalf("expected resume of an unsubscribed chat to be forbidden, got %+v", reply)
+	}
+}
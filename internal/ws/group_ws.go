@@ -18,6 +18,7 @@ type GroupWebSocketHandler struct {
 	hub        *Hub
 	groupRepo  repositories.GroupRepository
 	authClient *grpcclient.AuthClient
+	actions    GroupActions
 }
 
 // NewGroupWebSocketHandler constructs a GroupWebSocketHandler.
@@ -25,6 +26,11 @@ func NewGroupWebSocketHandler(hub *Hub, groupRepo repositories.GroupRepository,
 	return &GroupWebSocketHandler{hub: hub, groupRepo: groupRepo, authClient: authClient}
 }
 
+// SetActions enables client requests (see GroupActions) on group sockets.
+func (h *GroupWebSocketHandler) SetActions(actions GroupActions) {
+	h.actions = actions
+}
+
 // Handle upgrades and registers a websocket connection for group chats.
 func (h *GroupWebSocketHandler) Handle(c *gin.Context) {
 	groupID, err := strconv.Atoi(c.Param("group_id"))
@@ -32,6 +38,11 @@ func (h *GroupWebSocketHandler) Handle(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
 		return
 	}
+	sinceSeq, ok := sinceSeqQuery(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since_seq"})
+		return
+	}
 
 	token := c.GetHeader("Authorization")
 	if token == "" {
@@ -57,18 +68,20 @@ func (h *GroupWebSocketHandler) Handle(c *gin.Context) {
 	if err != nil {
 		return
 	}
-	h.hub.AddGroupClient(groupID, conn)
+	var client *Client
+	if sinceSeq != nil {
+		client = h.hub.AddGroupClientSince(groupID, userID, conn, *sinceSeq)
+	} else {
+		client = h.hub.AddGroupClient(groupID, userID, conn)
+	}
 
 	go func() {
 		defer func() {
-			h.hub.RemoveGroupClient(groupID, conn)
+			h.hub.RemoveGroupClient(groupID, client)
 			conn.Close()
 		}()
-		for {
-			if _, _, err := conn.ReadMessage(); err != nil {
-				return
-			}
-		}
+		d := &dispatcher{hub: h.hub, groups: h.actions, groupID: groupID}
+		d.serve(client)
 	}()
 }
 
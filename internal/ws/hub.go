@@ -2,137 +2,372 @@ package ws
 
 import (
 	"encoding/json"
-	"log"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 
 	"chat-service/internal/models"
 )
 
-// Hub maintains active websocket rooms.
+// Hub maintains active websocket rooms. Each room maps a connection to its client.
+// Multiplexed connections (see UserWebSocketHandler) are indexed by user id instead and receive
+// the events of every chat and group the user is subscribed to.
 type Hub struct {
-	chatRooms  map[int]map[*websocket.Conn]bool
-	groupRooms map[int]map[*websocket.Conn]bool
+	chatRooms  map[int]map[*Client]struct{}
+	groupRooms map[int]map[*Client]struct{}
+
+	users      map[int]*userSession
+	chatUsers  map[int]map[int]struct{}
+	groupUsers map[int]map[int]struct{}
 	mu         sync.RWMutex
+
+	typing *typingTracker
+
+	connections map[int]int
+	presence    PresenceListener
+	remote      map[string]*remoteInstance
+	remoteTTL   time.Duration
+
+	blocks      map[int]map[int]struct{}
+	blockLoads  map[int]*blockLoad
+	blockSource BlockSource
+
+	chatLogs  map[int]*roomLog
+	groupLogs map[int]*roomLog
+	seqBase   int64
+
+	cfg HubConfig
+
+	backplane  Backplane
+	instanceID string
 }
 
-// NewHub creates an empty hub.
+// NewHub creates an empty hub with DefaultHubConfig.
 func NewHub() *Hub {
+	return NewHubWithConfig(DefaultHubConfig())
+}
+
+// NewHubWithConfig creates an empty hub tuned by cfg.
+func NewHubWithConfig(cfg HubConfig) *Hub {
 	return &Hub{
-		chatRooms:  make(map[int]map[*websocket.Conn]bool),
-		groupRooms: make(map[int]map[*websocket.Conn]bool),
+		chatRooms:   make(map[int]map[*Client]struct{}),
+		groupRooms:  make(map[int]map[*Client]struct{}),
+		users:       make(map[int]*userSession),
+		chatUsers:   make(map[int]map[int]struct{}),
+		groupUsers:  make(map[int]map[int]struct{}),
+		typing:      newTypingTracker(DefaultTypingTTL),
+		connections: make(map[int]int),
+		remote:      make(map[string]*remoteInstance),
+		blocks:      make(map[int]map[int]struct{}),
+		blockLoads:  make(map[int]*blockLoad),
+		chatLogs:    make(map[int]*roomLog),
+		groupLogs:   make(map[int]*roomLog),
+		seqBase:     hubSeqBase(),
+		cfg:         cfg.withDefaults(),
+		instanceID:  uuid.NewString(),
 	}
 }
 
-// AddChatClient registers a websocket connection to a chat room.
-func (h *Hub) AddChatClient(chatID int, conn *websocket.Conn) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-	if _, ok := h.chatRooms[chatID]; !ok {
-		h.chatRooms[chatID] = make(map[*websocket.Conn]bool)
-	}
-	h.chatRooms[chatID][conn] = true
+// AddChatClient registers a user's websocket connection to a chat room.
+func (h *Hub) AddChatClient(chatID int, userID int, conn *websocket.Conn) *Client {
+	return h.addRoomClient(roomKey{id: chatID}, h.startClient(conn, userID), nil)
 }
 
-// RemoveChatClient removes a chat websocket connection.
-func (h *Hub) RemoveChatClient(chatID int, conn *websocket.Conn) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-	if conns, ok := h.chatRooms[chatID]; ok {
-		delete(conns, conn)
-		if len(conns) == 0 {
-			delete(h.chatRooms, chatID)
-		}
-	}
+// RemoveChatClient removes a chat connection.
+func (h *Hub) RemoveChatClient(chatID int, client *Client) {
+	h.removeRoomClient(roomKey{id: chatID}, client)
 }
 
 // BroadcastChatMessage sends message to all clients in a chat.
 func (h *Hub) BroadcastChatMessage(chatID int, msg models.Message) {
-	h.mu.RLock()
-	conns := h.chatRooms[chatID]
-	h.mu.RUnlock()
+	h.broadcastChat(chatID, models.ChatEvent{Type: "message", Message: &msg})
+}
 
-	event := models.ChatEvent{Type: "message", Message: &msg}
-	payload, _ := json.Marshal(event)
-	for conn := range conns {
-		if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
-			log.Printf("websocket write error: %v", err)
-			conn.Close()
-			h.RemoveChatClient(chatID, conn)
-		}
-	}
+// BroadcastChatEdit notifies clients that a message was edited.
+func (h *Hub) BroadcastChatEdit(chatID int, msg models.Message) {
+	h.broadcastChat(chatID, models.ChatEvent{Type: "edit", Message: &msg})
 }
 
 // BroadcastDeletion notifies clients of a delete-for-all event.
 func (h *Hub) BroadcastDeletion(chatID int, messageID int) {
-	h.mu.RLock()
-	conns := h.chatRooms[chatID]
-	h.mu.RUnlock()
+	h.broadcastChat(chatID, models.ChatEvent{Type: "delete_for_all", MessageID: messageID})
+}
 
-	event := models.ChatEvent{Type: "delete_for_all", MessageID: messageID}
-	payload, _ := json.Marshal(event)
-	for conn := range conns {
-		if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
-			log.Printf("websocket write error: %v", err)
-			conn.Close()
-			h.RemoveChatClient(chatID, conn)
-		}
-	}
+// BroadcastChatReaction notifies clients that a reaction was added or removed.
+func (h *Hub) BroadcastChatReaction(chatID int, added bool, reaction models.Reaction) {
+	h.broadcastChat(chatID, models.ChatEvent{Type: reactionEventType(added), MessageID: reaction.MessageID, Reaction: &reaction})
 }
 
-// AddGroupClient registers a websocket connection to a group room.
-func (h *Hub) AddGroupClient(groupID int, conn *websocket.Conn) {
+// BroadcastChatRead notifies clients that a participant read the chat up to messageID.
+func (h *Hub) BroadcastChatRead(chatID int, userID int, messageID int) {
+	h.broadcastChat(chatID, models.ChatEvent{Type: "read", UserID: userID, MessageID: messageID})
+}
+
+// BroadcastChatReadOnly notifies clients that the chat no longer accepts messages.
+func (h *Hub) BroadcastChatReadOnly(chatID int, reason string) {
+	h.broadcastChat(chatID, models.ChatEvent{Type: "read_only", Reason: reason})
+}
+
+// broadcastChat delivers a chat event on every instance.
+func (h *Hub) broadcastChat(chatID int, event models.ChatEvent) {
+	h.broadcastChatLocal(chatID, event)
+	h.relay(relayMessage{Op: relayChatEvent, ChatID: chatID}, event)
+}
+
+// broadcastChatLocal assigns event the chat's next sequence number, records it for replay and
+// delivers it to the chat room and to subscribed multiplexed connections.
+func (h *Hub) broadcastChatLocal(chatID int, event models.ChatEvent) {
+	event.ChatID = chatID
+	sender := chatEventSender(event)
 	h.mu.Lock()
-	defer h.mu.Unlock()
-	if _, ok := h.groupRooms[groupID]; !ok {
-		h.groupRooms[groupID] = make(map[*websocket.Conn]bool)
+	eventLog := h.logLocked(roomKey{id: chatID})
+	event.Seq = eventLog.next()
+	payload, _ := json.Marshal(event)
+	eventLog.append(event.Seq, payload, sender)
+	clients := h.roomClientsLocked(h.chatRooms, chatID, 0)
+	h.mu.Unlock()
+
+	h.deliverChat(chatID, clients, payload, 0, sender)
+}
+
+// sendChat delivers an ephemeral chat event on every instance.
+func (h *Hub) sendChat(chatID int, event models.ChatEvent, skipUserID int) {
+	h.sendChatLocal(chatID, event, skipUserID)
+	h.relay(relayMessage{Op: relayChatEphemeral, ChatID: chatID, UserID: skipUserID}, event)
+}
+
+// sendChatLocal delivers an ephemeral event, which is neither sequenced nor replayed, skipping the
+// connections of skipUserID (0 delivers to everyone).
+func (h *Hub) sendChatLocal(chatID int, event models.ChatEvent, skipUserID int) {
+	event.ChatID = chatID
+	payload, _ := json.Marshal(event)
+	h.deliverChat(chatID, h.roomClients(h.chatRooms, chatID, skipUserID), payload, skipUserID, chatEventSender(event))
+}
+
+// deliverChat writes payload to the room's clients and subscribers, except those that blocked senderID.
+func (h *Hub) deliverChat(chatID int, clients []*Client, payload []byte, skipUserID int, senderID int) {
+	clients, userIDs := h.withoutBlockers(clients, h.subscribers(h.chatUsers, chatID, skipUserID), senderID)
+	for _, client := range clients {
+		client.write(payload)
 	}
-	h.groupRooms[groupID][conn] = true
+	h.deliverToUsers(userIDs, payload)
+}
+
+// AddGroupClient registers a user's websocket connection to a group room.
+func (h *Hub) AddGroupClient(groupID int, userID int, conn *websocket.Conn) *Client {
+	return h.addRoomClient(roomKey{group: true, id: groupID}, h.startClient(conn, userID), nil)
+}
+
+// RemoveGroupClient removes a group connection.
+func (h *Hub) RemoveGroupClient(groupID int, client *Client) {
+	h.removeRoomClient(roomKey{group: true, id: groupID}, client)
 }
 
-// RemoveGroupClient removes a group websocket connection.
-func (h *Hub) RemoveGroupClient(groupID int, conn *websocket.Conn) {
+// DisconnectGroupUser closes every group socket the user holds, e.g. after removal from the group.
+// Multiplexed connections stay open; they are unsubscribed from the group and receive a group_left event.
+func (h *Hub) DisconnectGroupUser(groupID int, userID int) {
+	h.disconnectGroupUserLocal(groupID, userID)
+	h.relay(relayMessage{Op: relayDisconnectGroupUser, GroupID: groupID, UserID: userID}, nil)
+}
+
+func (h *Hub) disconnectGroupUserLocal(groupID int, userID int) {
+	h.stopTyping(typingKey{group: true, id: groupID, userID: userID})
+	h.unsubscribeGroupLocal(groupID, userID)
+
 	h.mu.Lock()
-	defer h.mu.Unlock()
-	if conns, ok := h.groupRooms[groupID]; ok {
-		delete(conns, conn)
-		if len(conns) == 0 {
-			delete(h.groupRooms, groupID)
+	var (
+		conns    []*websocket.Conn
+		listener PresenceListener
+	)
+	for client := range h.groupRooms[groupID] {
+		if client.userID == userID {
+			client.close()
+			if client.conn != nil {
+				conns = append(conns, client.conn)
+			}
+			delete(h.groupRooms[groupID], client)
+			if l := h.disconnectedLocked(userID); l != nil {
+				listener = l
+			}
 		}
 	}
+	if len(h.groupRooms[groupID]) == 0 {
+		delete(h.groupRooms, groupID)
+	}
+	h.mu.Unlock()
+
+	if listener != nil {
+		listener.UserDisconnected(userID)
+	}
+
+	closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "removed from group")
+	for _, conn := range conns {
+		_ = conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+		conn.Close()
+	}
 }
 
 // BroadcastGroupMessage sends message to all clients in a group.
 func (h *Hub) BroadcastGroupMessage(groupID int, msg models.GroupMessage) {
-	h.mu.RLock()
-	conns := h.groupRooms[groupID]
-	h.mu.RUnlock()
+	h.broadcastGroup(groupID, models.GroupEvent{Type: "message", Message: &msg})
+}
 
-	event := models.GroupEvent{Type: "message", Message: &msg}
-	payload, _ := json.Marshal(event)
-	for conn := range conns {
-		if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
-			log.Printf("websocket write error: %v", err)
-			conn.Close()
-			h.RemoveGroupClient(groupID, conn)
-		}
-	}
+// BroadcastGroupEdit notifies clients that a group message was edited.
+func (h *Hub) BroadcastGroupEdit(groupID int, msg models.GroupMessage) {
+	h.broadcastGroup(groupID, models.GroupEvent{Type: "edit", Message: &msg})
 }
 
 // BroadcastGroupDeletion notifies clients of a delete-for-all event.
 func (h *Hub) BroadcastGroupDeletion(groupID int, messageID int) {
-	h.mu.RLock()
-	conns := h.groupRooms[groupID]
-	h.mu.RUnlock()
+	h.broadcastGroup(groupID, models.GroupEvent{Type: "delete_for_all", MessageID: messageID})
+}
+
+// BroadcastGroupReaction notifies clients that a reaction was added or removed.
+func (h *Hub) BroadcastGroupReaction(groupID int, added bool, reaction models.Reaction) {
+	h.broadcastGroup(groupID, models.GroupEvent{Type: reactionEventType(added), MessageID: reaction.MessageID, Reaction: &reaction})
+}
 
-	event := models.GroupEvent{Type: "delete_for_all", MessageID: messageID}
+// BroadcastGroupRead notifies clients that a member read the group up to messageID.
+func (h *Hub) BroadcastGroupRead(groupID int, userID int, messageID int) {
+	h.broadcastGroup(groupID, models.GroupEvent{Type: "read", UserID: userID, MessageID: messageID})
+}
+
+// BroadcastGroupUpdate notifies clients that the group's settings changed.
+func (h *Hub) BroadcastGroupUpdate(group models.Group) {
+	h.broadcastGroup(group.ID, models.GroupEvent{Type: "group_updated", Group: &group})
+}
+
+// broadcastGroup delivers a group event on every instance.
+func (h *Hub) broadcastGroup(groupID int, event models.GroupEvent) {
+	h.broadcastGroupLocal(groupID, event)
+	h.relay(relayMessage{Op: relayGroupEvent, GroupID: groupID}, event)
+}
+
+// broadcastGroupLocal assigns event the group's next sequence number, records it for replay and
+// delivers it to the group room and to subscribed multiplexed connections.
+func (h *Hub) broadcastGroupLocal(groupID int, event models.GroupEvent) {
+	event.GroupID = groupID
+	sender := groupEventSender(event)
+	h.mu.Lock()
+	eventLog := h.logLocked(roomKey{group: true, id: groupID})
+	event.Seq = eventLog.next()
 	payload, _ := json.Marshal(event)
-	for conn := range conns {
-		if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
-			log.Printf("websocket write error: %v", err)
-			conn.Close()
-			h.RemoveGroupClient(groupID, conn)
+	eventLog.append(event.Seq, payload, sender)
+	clients := h.roomClientsLocked(h.groupRooms, groupID, 0)
+	h.mu.Unlock()
+
+	h.deliverGroup(groupID, clients, payload, 0, sender)
+}
+
+// sendGroup delivers an ephemeral group event on every instance.
+func (h *Hub) sendGroup(groupID int, event models.GroupEvent, skipUserID int) {
+	h.sendGroupLocal(groupID, event, skipUserID)
+	h.relay(relayMessage{Op: relayGroupEphemeral, GroupID: groupID, UserID: skipUserID}, event)
+}
+
+// sendGroupLocal delivers an ephemeral event, which is neither sequenced nor replayed, skipping the
+// connections of skipUserID (0 delivers to everyone).
+func (h *Hub) sendGroupLocal(groupID int, event models.GroupEvent, skipUserID int) {
+	event.GroupID = groupID
+	payload, _ := json.Marshal(event)
+	h.deliverGroup(groupID, h.roomClients(h.groupRooms, groupID, skipUserID), payload, skipUserID, groupEventSender(event))
+}
+
+// deliverGroup writes payload to the room's clients and subscribers, except those that blocked senderID.
+func (h *Hub) deliverGroup(groupID int, clients []*Client, payload []byte, skipUserID int, senderID int) {
+	clients, userIDs := h.withoutBlockers(clients, h.subscribers(h.groupUsers, groupID, skipUserID), senderID)
+	for _, client := range clients {
+		client.write(payload)
+	}
+	h.deliverToUsers(userIDs, payload)
+}
+
+// roomClients snapshots a room's clients so writes happen outside the lock.
+func (h *Hub) roomClients(rooms map[int]map[*Client]struct{}, id int, skipUserID int) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.roomClientsLocked(rooms, id, skipUserID)
+}
+
+func (h *Hub) roomClientsLocked(rooms map[int]map[*Client]struct{}, id int, skipUserID int) []*Client {
+	clients := make([]*Client, 0, len(rooms[id]))
+	for client := range rooms[id] {
+		if skipUserID != 0 && client.userID == skipUserID {
+			continue
+		}
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// addRoomClient registers a room connection. With sinceSeq set, the events missed since then
+// are queued before the lock is released, so live broadcasts always follow the replay.
+func (h *Hub) addRoomClient(room roomKey, client *Client, sinceSeq *int64) *Client {
+	rooms := h.rooms(room)
+	blocked := h.loadBlocks(client.userID)
+
+	h.mu.Lock()
+	h.storeBlocksLocked(client.userID, blocked)
+	if _, ok := rooms[room.id]; !ok {
+		rooms[room.id] = make(map[*Client]struct{})
+	}
+	rooms[room.id][client] = struct{}{}
+	listener := h.connectedLocked(client.userID)
+	if sinceSeq != nil {
+		for _, payload := range h.replayPayloadsLocked(room, *sinceSeq, client.room(), client.userID) {
+			client.write(payload)
+		}
+	}
+	h.mu.Unlock()
+
+	if listener != nil {
+		listener.UserConnected(client.userID)
+	}
+	return client
+}
+
+// removeRoomClient unregisters a room connection and stops its client. Removing a client
+// twice is a no-op.
+func (h *Hub) removeRoomClient(room roomKey, client *Client) {
+	rooms := h.rooms(room)
+
+	h.mu.Lock()
+	var listener PresenceListener
+	_, ok := rooms[room.id][client]
+	if ok {
+		delete(rooms[room.id], client)
+		if len(rooms[room.id]) == 0 {
+			delete(rooms, room.id)
 		}
+		listener = h.disconnectedLocked(client.userID)
+		client.close()
+	}
+	h.mu.Unlock()
+
+	if listener != nil {
+		listener.UserDisconnected(client.userID)
+	}
+}
+
+func (h *Hub) rooms(room roomKey) map[int]map[*Client]struct{} {
+	if room.group {
+		return h.groupRooms
+	}
+	return h.chatRooms
+}
+
+// startClient creates a client and starts its write pump.
+func (h *Hub) startClient(conn *websocket.Conn, userID int) *Client {
+	client := newClient(conn, userID, h.cfg)
+	go client.writePump()
+	return client
+}
+
+func reactionEventType(added bool) string {
+	if added {
+		return "reaction_added"
 	}
+	return "reaction_removed"
 }
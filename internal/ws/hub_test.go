@@ -1,16 +1,26 @@
 package ws
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"chat-service/internal/models"
+)
 
 func TestHubAddAndRemoveChatClient(t *testing.T) {
 	hub := NewHub()
 
-	hub.AddChatClient(1, nil)
+	client := hub.AddChatClient(1, 10, nil)
 	if len(hub.chatRooms) != 1 {
 		t.Fatalf("expected chat room to be created")
 	}
 
-	hub.RemoveChatClient(1, nil)
+	hub.RemoveChatClient(1, client)
 	if len(hub.chatRooms) != 0 {
 		t.Fatalf("expected chat room to be removed")
 	}
@@ -19,13 +29,118 @@ func TestHubAddAndRemoveChatClient(t *testing.T) {
 func TestHubAddAndRemoveGroupClient(t *testing.T) {
 	hub := NewHub()
 
-	hub.AddGroupClient(2, nil)
+	client := hub.AddGroupClient(2, 10, nil)
 	if len(hub.groupRooms) != 1 {
 		t.Fatalf("expected group room to be created")
 	}
 
-	hub.RemoveGroupClient(2, nil)
+	hub.RemoveGroupClient(2, client)
 	if len(hub.groupRooms) != 0 {
 		t.Fatalf("expected group room to be removed")
 	}
 }
+
+func TestHubDisconnectGroupUser(t *testing.T) {
+	hub := NewHub()
+	removedServer, removedClient := newTestConnPair(t)
+	keptServer, _ := newTestConnPair(t)
+
+	removed := hub.AddGroupClient(2, 10, removedServer)
+	kept := hub.AddGroupClient(2, 11, keptServer)
+
+	hub.DisconnectGroupUser(2, 10)
+
+	if _, ok := hub.groupRooms[2][removed]; ok {
+		t.Fatalf("expected removed user's socket to leave the room")
+	}
+	if _, ok := hub.groupRooms[2][kept]; !ok {
+		t.Fatalf("expected other members to stay connected")
+	}
+
+	removedClient.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := removedClient.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("expected policy violation close, got %v", err)
+	}
+}
+
+func TestHubUserClientReceivesSubscribedConversations(t *testing.T) {
+	hub := NewHub()
+	server, client := newTestConnPair(t)
+
+	hub.AddUserClient(10, server, []int{1}, nil)
+	hub.BroadcastChatMessage(1, models.Message{ID: 5, ChatID: 1})
+	if event := readEvent(t, client); event["type"] != "message" || event["chat_id"] != float64(1) {
+		t.Fatalf("expected chat message event, got %v", event)
+	}
+
+	hub.SubscribeGroup(2, 10)
+	if event := readEvent(t, client); event["type"] != "group_joined" || event["group_id"] != float64(2) {
+		t.Fatalf("expected group_joined event, got %v", event)
+	}
+	hub.BroadcastGroupDeletion(2, 7)
+	if event := readEvent(t, client); event["type"] != "delete_for_all" || event["group_id"] != float64(2) {
+		t.Fatalf("expected group deletion event, got %v", event)
+	}
+
+	hub.DisconnectGroupUser(2, 10)
+	if event := readEvent(t, client); event["type"] != "group_left" {
+		t.Fatalf("expected group_left event, got %v", event)
+	}
+	if _, ok := hub.groupUsers[2]; ok {
+		t.Fatalf("expected user to be unsubscribed from group")
+	}
+}
+
+func TestHubRemoveUserClientDropsSubscriptions(t *testing.T) {
+	hub := NewHub()
+
+	client := hub.AddUserClient(10, nil, []int{1}, []int{2})
+	hub.SubscribeChat(3, 10, 11)
+	if _, ok := hub.chatUsers[3][11]; ok {
+		t.Fatalf("expected offline users to be skipped")
+	}
+
+	hub.RemoveUserClient(client)
+	if len(hub.users) != 0 || len(hub.chatUsers) != 0 || len(hub.groupUsers) != 0 {
+		t.Fatalf("expected subscriptions to be dropped with the last connection")
+	}
+}
+
+// readEvent reads one JSON event from the client end of a test connection.
+func readEvent(t *testing.T, conn *websocket.Conn) map[string]any {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var event map[string]any
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	return event
+}
+
+// newTestConnPair returns the server and client ends of a live websocket connection.
+func newTestConnPair(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	t.Helper()
+
+	serverConns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		serverConns <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	server := <-serverConns
+	t.Cleanup(func() { server.Close() })
+	return server, client
+}
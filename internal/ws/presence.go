@@ -0,0 +1,168 @@
+package ws
+
+import (
+	"context"
+	"time"
+)
+
+// PresenceListener is told when a user's first websocket connection on this instance opens and
+// when their last one closes, across chat, group and multiplexed sockets. Calls are made outside
+// the hub lock. Use Hub.Online and Hub.OnlineElsewhere to account for other instances.
+type PresenceListener interface {
+	UserConnected(userID int)
+	UserDisconnected(userID int)
+}
+
+// SetPresenceListener registers the listener notified of online/offline transitions.
+func (h *Hub) SetPresenceListener(listener PresenceListener) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.presence = listener
+}
+
+// Online reports whether the user holds at least one websocket connection on any instance.
+func (h *Hub) Online(userID int) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.connections[userID] > 0 || h.onlineElsewhereLocked(userID)
+}
+
+// OnlineElsewhere reports whether another instance holds a websocket connection of the user.
+func (h *Hub) OnlineElsewhere(userID int) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.onlineElsewhereLocked(userID)
+}
+
+// remoteInstance is what the hub knows about the connected users of another instance, from its
+// relayed connect, disconnect and sync operations.
+type remoteInstance struct {
+	users  map[int]struct{}
+	seenAt time.Time
+}
+
+// onlineElsewhereLocked reports whether a live instance other than this one holds a connection
+// of the user. h.mu must be held.
+func (h *Hub) onlineElsewhereLocked(userID int) bool {
+	for _, instance := range h.remote {
+		if _, ok := instance.users[userID]; ok && h.remoteLiveLocked(instance) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteLiveLocked reports whether instance was heard from recently enough to be trusted.
+// Without presence sync running, instances never expire. h.mu must be held.
+func (h *Hub) remoteLiveLocked(instance *remoteInstance) bool {
+	return h.remoteTTL == 0 || time.Since(instance.seenAt) <= h.remoteTTL
+}
+
+// RunPresenceSync announces this instance's connected users to the others every interval until
+// ctx is cancelled. Instances that have not been heard from for three intervals, e.g. because
+// they crashed, no longer count towards presence.
+func (h *Hub) RunPresenceSync(ctx context.Context, interval time.Duration) {
+	h.mu.Lock()
+	h.remoteTTL = 3 * interval
+	h.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.mu.Lock()
+		userIDs := make([]int, 0, len(h.connections))
+		for userID := range h.connections {
+			userIDs = append(userIDs, userID)
+		}
+		for origin, instance := range h.remote {
+			if !h.remoteLiveLocked(instance) {
+				delete(h.remote, origin)
+			}
+		}
+		h.mu.Unlock()
+		h.relay(relayMessage{Op: relayPresenceSync, UserIDs: userIDs}, nil)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setRemotePresence applies a presence operation relayed by origin. With sync set, userIDs
+// replace everything known about origin; otherwise they connected or disconnected.
+func (h *Hub) setRemotePresence(origin string, userIDs []int, online bool, sync bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	instance, ok := h.remote[origin]
+	if !ok || sync {
+		instance = &remoteInstance{users: make(map[int]struct{}, len(userIDs))}
+		h.remote[origin] = instance
+	}
+	instance.seenAt = time.Now()
+	for _, userID := range userIDs {
+		if online {
+			instance.users[userID] = struct{}{}
+		} else {
+			delete(instance.users, userID)
+		}
+	}
+}
+
+// presenceRelay is handed out by connectedLocked and disconnectedLocked on a user's first and
+// last connection to this instance. It tells the other instances, then the registered listener.
+type presenceRelay struct {
+	h *Hub
+}
+
+func (p presenceRelay) UserConnected(userID int) {
+	p.h.relay(relayMessage{Op: relayUserOnline, UserID: userID}, nil)
+	if listener := p.h.presenceListener(); listener != nil {
+		listener.UserConnected(userID)
+	}
+}
+
+func (p presenceRelay) UserDisconnected(userID int) {
+	p.h.relay(relayMessage{Op: relayUserOffline, UserID: userID}, nil)
+	if listener := p.h.presenceListener(); listener != nil {
+		listener.UserDisconnected(userID)
+	}
+}
+
+func (h *Hub) presenceListener() PresenceListener {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.presence
+}
+
+// PushToUsers sends event to the multiplexed connections of userIDs on every instance.
+// Per-conversation sockets only receive events about their own conversation.
+func (h *Hub) PushToUsers(userIDs []int, event any) {
+	h.notifyUsers(userIDs, event)
+	h.relay(relayMessage{Op: relayPush, UserIDs: userIDs}, event)
+}
+
+// connectedLocked counts a new connection and returns the listener to notify when it is the user's
+// first on this instance. h.mu must be held.
+func (h *Hub) connectedLocked(userID int) PresenceListener {
+	h.connections[userID]++
+	if h.connections[userID] == 1 {
+		return presenceRelay{h: h}
+	}
+	return nil
+}
+
+// disconnectedLocked uncounts a connection and returns the listener to notify when it was the
+// user's last on this instance. h.mu must be held.
+func (h *Hub) disconnectedLocked(userID int) PresenceListener {
+	h.connections[userID]--
+	if h.connections[userID] > 0 {
+		return nil
+	}
+	delete(h.connections, userID)
+	if _, loading := h.blockLoads[userID]; !loading {
+		delete(h.blocks, userID)
+	}
+	return presenceRelay{h: h}
+}
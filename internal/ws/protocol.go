@@ -0,0 +1,252 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"chat-service/internal/models"
+)
+
+// Client request types accepted on every socket. FrameTyping is the original name of
+// FrameTypingStarted and is still accepted.
+const (
+	FrameSendMessage   = "send_message"
+	FrameDelete        = "delete"
+	FrameTyping        = "typing"
+	FrameTypingStarted = "typing_started"
+	FrameTypingStopped = "typing_stopped"
+	FrameRead          = "read"
+	FrameResume        = "resume"
+)
+
+// requestTimeout bounds the work done for a single client request.
+const requestTimeout = 10 * time.Second
+
+// ChatActions performs client requests against private chats. It is implemented by the REST
+// handlers so that both transports share validation and persistence.
+type ChatActions interface {
+	SendMessage(ctx context.Context, userID, chatID int, content string, replyToID *int, attachmentIDs []int) (models.Message, error)
+	DeleteMessage(ctx context.Context, userID, chatID, messageID int) error
+	MarkRead(ctx context.Context, userID, chatID, messageID int) (int, error)
+}
+
+// GroupActions performs client requests against groups.
+type GroupActions interface {
+	SendMessage(ctx context.Context, userID, groupID int, content string, replyToID *int, attachmentIDs []int) (models.GroupMessage, error)
+	DeleteMessage(ctx context.Context, userID, groupID, messageID int) error
+	MarkRead(ctx context.Context, userID, groupID, messageID int) (int, error)
+}
+
+// StatusError is implemented by action errors that map to an HTTP status.
+// Errors that do not implement it are reported to the client as internal errors.
+type StatusError interface {
+	error
+	StatusCode() int
+}
+
+// clientFrame is a request sent by a client. ID is generated by the client and echoed in the reply.
+type clientFrame struct {
+	ID               string `json:"id"`
+	Type             string `json:"type"`
+	ChatID           int    `json:"chat_id"`
+	GroupID          int    `json:"group_id"`
+	MessageID        int    `json:"message_id"`
+	Content          string `json:"content"`
+	ReplyToMessageID *int   `json:"reply_to_message_id"`
+	AttachmentIDs    []int  `json:"attachment_ids"`
+	SinceSeq         *int64 `json:"since_seq"`
+}
+
+// replyFrame answers a client request with either an ack or an error.
+type replyFrame struct {
+	Type   string `json:"type"`
+	ID     string `json:"id,omitempty"`
+	Result any    `json:"result,omitempty"`
+	Code   int    `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// dispatcher routes client requests from one connection. Per-conversation sockets fix chatID or
+// groupID; the multiplexed socket leaves both zero and takes the target from each frame.
+type dispatcher struct {
+	hub     *Hub
+	chats   ChatActions
+	groups  GroupActions
+	chatID  int
+	groupID int
+
+	// typingAt records when this connection last fanned out typing_started per conversation.
+	typingAt map[typingKey]time.Time
+}
+
+// serve reads frames until the connection fails, answering each request in order.
+// Typing indicators started by the connection are cleared when it closes.
+func (d *dispatcher) serve(client *Client) {
+	defer func() {
+		for key := range d.typingAt {
+			d.hub.stopTyping(key)
+		}
+	}()
+	client.keepAlive()
+	for {
+		_, raw, err := client.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		reply := d.handle(client, raw)
+		if err := client.writeJSON(reply); errors.Is(err, errClientClosed) {
+			return
+		}
+	}
+}
+
+func (d *dispatcher) handle(client *Client, raw []byte) replyFrame {
+	var frame clientFrame
+	if err := json.Unmarshal(raw, &frame); err != nil {
+		return errorReply("", http.StatusBadRequest, "invalid frame")
+	}
+
+	chatID, groupID, msg := d.target(frame)
+	if msg != "" {
+		return errorReply(frame.ID, http.StatusBadRequest, msg)
+	}
+	if frame.Type == FrameResume {
+		return d.resume(client, frame, chatID, groupID)
+	}
+	if (chatID != 0 && d.chats == nil) || (groupID != 0 && d.groups == nil) {
+		return errorReply(frame.ID, http.StatusNotImplemented, "requests are not supported on this socket")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	userID := client.userID
+	var (
+		result any
+		err    error
+	)
+	switch frame.Type {
+	case FrameSendMessage:
+		if chatID != 0 {
+			result, err = d.chats.SendMessage(ctx, userID, chatID, frame.Content, frame.ReplyToMessageID, frame.AttachmentIDs)
+		} else {
+			result, err = d.groups.SendMessage(ctx, userID, groupID, frame.Content, frame.ReplyToMessageID, frame.AttachmentIDs)
+		}
+	case FrameDelete:
+		if frame.MessageID <= 0 {
+			return errorReply(frame.ID, http.StatusBadRequest, "invalid message id")
+		}
+		if chatID != 0 {
+			err = d.chats.DeleteMessage(ctx, userID, chatID, frame.MessageID)
+		} else {
+			err = d.groups.DeleteMessage(ctx, userID, groupID, frame.MessageID)
+		}
+	case FrameRead:
+		if frame.MessageID < 0 {
+			return errorReply(frame.ID, http.StatusBadRequest, "invalid message id")
+		}
+		var lastRead int
+		if chatID != 0 {
+			lastRead, err = d.chats.MarkRead(ctx, userID, chatID, frame.MessageID)
+		} else {
+			lastRead, err = d.groups.MarkRead(ctx, userID, groupID, frame.MessageID)
+		}
+		result = map[string]int{"last_read_message_id": lastRead}
+	case FrameTyping, FrameTypingStarted, FrameTypingStopped:
+		if !d.subscribedTo(userID, chatID, groupID) {
+			return errorReply(frame.ID, http.StatusForbidden, "not a member")
+		}
+		key := typingKey{group: groupID != 0, id: chatID + groupID, userID: userID}
+		if frame.Type == FrameTypingStopped {
+			delete(d.typingAt, key)
+			d.hub.stopTyping(key)
+		} else {
+			d.startTyping(key)
+		}
+	default:
+		return errorReply(frame.ID, http.StatusBadRequest, "unknown frame type")
+	}
+
+	if err == nil && frame.Type == FrameSendMessage {
+		// a sent message ends the sender's typing indicator
+		key := typingKey{group: groupID != 0, id: chatID + groupID, userID: userID}
+		delete(d.typingAt, key)
+	}
+	if err != nil {
+		var statusErr StatusError
+		if errors.As(err, &statusErr) {
+			return errorReply(frame.ID, statusErr.StatusCode(), statusErr.Error())
+		}
+		log.Printf("websocket request %s failed: %v", frame.Type, err)
+		return errorReply(frame.ID, http.StatusInternalServerError, "internal error")
+	}
+	return replyFrame{Type: "ack", ID: frame.ID, Result: result}
+}
+
+// startTyping fans out typing_started at most once per TypingThrottle for this connection.
+func (d *dispatcher) startTyping(key typingKey) {
+	now := time.Now()
+	if last, ok := d.typingAt[key]; ok && now.Sub(last) < TypingThrottle {
+		return
+	}
+	if d.typingAt == nil {
+		d.typingAt = make(map[typingKey]time.Time)
+	}
+	d.typingAt[key] = now
+	d.hub.startTyping(key)
+}
+
+// target resolves the conversation a frame addresses, returning a message when it is invalid.
+func (d *dispatcher) target(frame clientFrame) (int, int, string) {
+	switch {
+	case d.chatID != 0:
+		if frame.GroupID != 0 || (frame.ChatID != 0 && frame.ChatID != d.chatID) {
+			return 0, 0, "frame does not target this chat"
+		}
+		return d.chatID, 0, ""
+	case d.groupID != 0:
+		if frame.ChatID != 0 || (frame.GroupID != 0 && frame.GroupID != d.groupID) {
+			return 0, 0, "frame does not target this group"
+		}
+		return 0, d.groupID, ""
+	case (frame.ChatID > 0) == (frame.GroupID > 0):
+		return 0, 0, "exactly one of chat_id or group_id is required"
+	default:
+		return frame.ChatID, frame.GroupID, ""
+	}
+}
+
+// resume replays the events of the frame's conversation after since_seq before acknowledging.
+func (d *dispatcher) resume(client *Client, frame clientFrame, chatID, groupID int) replyFrame {
+	if frame.SinceSeq == nil || *frame.SinceSeq < 0 {
+		return errorReply(frame.ID, http.StatusBadRequest, "invalid since_seq")
+	}
+	if !d.subscribedTo(client.userID, chatID, groupID) {
+		return errorReply(frame.ID, http.StatusForbidden, "not a member")
+	}
+	room := roomKey{group: groupID != 0, id: chatID + groupID}
+	if err := d.hub.resume(client, room, *frame.SinceSeq); err != nil {
+		log.Printf("websocket replay error: %v", err)
+	}
+	return replyFrame{Type: "ack", ID: frame.ID}
+}
+
+// subscribedTo checks membership without a database round trip: per-conversation sockets were
+// authorized on connect and multiplexed sockets track the user's subscriptions.
+func (d *dispatcher) subscribedTo(userID, chatID, groupID int) bool {
+	if d.chatID != 0 || d.groupID != 0 {
+		return true
+	}
+	if chatID != 0 {
+		return d.hub.subscribed(d.hub.chatUsers, chatID, userID)
+	}
+	return d.hub.subscribed(d.hub.groupUsers, groupID, userID)
+}
+
+func errorReply(id string, code int, msg string) replyFrame {
+	return replyFrame{Type: "error", ID: id, Code: code, Error: msg}
+}
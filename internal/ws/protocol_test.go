@@ -0,0 +1,95 @@
+package ws
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"chat-service/internal/models"
+)
+
+type statusErr struct {
+	code int
+	msg  string
+}
+
+func (e statusErr) Error() string   { return e.msg }
+func (e statusErr) StatusCode() int { return e.code }
+
+type fakeChatActions struct {
+	sent    []string
+	sendErr error
+}
+
+func (f *fakeChatActions) SendMessage(ctx context.Context, userID, chatID int, content string, replyToID *int, attachmentIDs []int) (models.Message, error) {
+	if f.sendErr != nil {
+		return models.Message{}, f.sendErr
+	}
+	f.sent = append(f.sent, content)
+	return models.Message{ID: 77, ChatID: chatID, SenderID: userID, Content: content}, nil
+}
+
+func (f *fakeChatActions) DeleteMessage(ctx context.Context, userID, chatID, messageID int) error {
+	return nil
+}
+
+func (f *fakeChatActions) MarkRead(ctx context.Context, userID, chatID, messageID int) (int, error) {
+	return messageID, nil
+}
+
+func TestDispatcherSendMessageAck(t *testing.T) {
+	hub := NewHub()
+	actions := &fakeChatActions{}
+	d := &dispatcher{hub: hub, chats: actions, chatID: 3}
+
+	reply := d.handle(newClient(nil, 10, DefaultHubConfig()), []byte(`{"id":"c1","type":"send_message","content":"hi"}`))
+	if reply.Type != "ack" || reply.ID != "c1" {
+		t.Fatalf("expected ack for c1, got %+v", reply)
+	}
+	msg, ok := reply.Result.(models.Message)
+	if !ok || msg.ID != 77 || msg.SenderID != 10 || msg.ChatID != 3 {
+		t.Fatalf("expected stored message in ack, got %+v", reply.Result)
+	}
+}
+
+func TestDispatcherMapsStatusErrors(t *testing.T) {
+	d := &dispatcher{hub: NewHub(), chats: &fakeChatActions{sendErr: statusErr{code: http.StatusForbidden, msg: "not a chat member"}}, chatID: 3}
+
+	reply := d.handle(newClient(nil, 10, DefaultHubConfig()), []byte(`{"id":"c2","type":"send_message","content":"hi"}`))
+	if reply.Type != "error" || reply.Code != http.StatusForbidden || reply.Error != "not a chat member" {
+		t.Fatalf("expected forbidden error, got %+v", reply)
+	}
+}
+
+func TestDispatcherRejectsInvalidFrames(t *testing.T) {
+	d := &dispatcher{hub: NewHub(), chats: &fakeChatActions{}}
+	client := newClient(nil, 10, DefaultHubConfig())
+
+	cases := map[string]string{
+		`not json`: "invalid frame",
+		`{"id":"a","type":"send_message","content":"x"}`:              "exactly one of chat_id or group_id is required",
+		`{"id":"b","type":"send_message","chat_id":1,"group_id":2}`:   "exactly one of chat_id or group_id is required",
+		`{"id":"c","type":"shout","chat_id":1}`:                       "unknown frame type",
+		`{"id":"d","type":"delete","chat_id":1}`:                      "invalid message id",
+		`{"id":"e","type":"send_message","group_id":2,"content":"x"}`: "requests are not supported on this socket",
+	}
+	for raw, want := range cases {
+		reply := d.handle(client, []byte(raw))
+		if reply.Type != "error" || reply.Error != want {
+			t.Fatalf("frame %s: expected error %q, got %+v", raw, want, reply)
+		}
+	}
+}
+
+func TestDispatcherTypingRequiresSubscription(t *testing.T) {
+	hub := NewHub()
+	d := &dispatcher{hub: hub, chats: &fakeChatActions{}}
+
+	hub.AddUserClient(10, nil, []int{1}, nil)
+	if reply := d.handle(newClient(nil, 10, DefaultHubConfig()), []byte(`{"id":"t1","type":"typing","chat_id":2}`)); reply.Code != http.StatusForbidden {
+		t.Fatalf("expected typing in an unsubscribed chat to be forbidden, got %+v", reply)
+	}
+	if reply := d.handle(newClient(nil, 10, DefaultHubConfig()), []byte(`{"id":"t2","type":"typing","chat_id":1}`)); reply.Type != "ack" {
+		t.Fatalf("expected typing ack, got %+v", reply)
+	}
+}
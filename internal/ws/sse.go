@@ -0,0 +1,212 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	grpcclient "chat-service/internal/grpc"
+	"chat-service/internal/repositories"
+)
+
+// SSEHandler streams hub events as Server-Sent Events, for clients behind proxies that block
+// websocket upgrades. Streams carry the same payloads as the websockets and are receive-only.
+type SSEHandler struct {
+	hub        *Hub
+	chatRepo   repositories.ChatRepository
+	groupRepo  repositories.GroupRepository
+	authClient *grpcclient.AuthClient
+}
+
+// NewSSEHandler constructs an SSEHandler.
+func NewSSEHandler(hub *Hub, chatRepo repositories.ChatRepository, groupRepo repositories.GroupRepository, authClient *grpcclient.AuthClient) *SSEHandler {
+	return &SSEHandler{hub: hub, chatRepo: chatRepo, groupRepo: groupRepo, authClient: authClient}
+}
+
+// Chat streams a chat's events. Last-Event-ID (or since_seq) resumes after that seq.
+func (h *SSEHandler) Chat(c *gin.Context) {
+	chatID, err := strconv.Atoi(c.Param("chat_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return
+	}
+	sinceSeq, ok := streamCursor(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Last-Event-ID"})
+		return
+	}
+
+	userID, err := h.authenticate(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	member, err := h.chatRepo.IsParticipant(c.Request.Context(), chatID, userID)
+	if err != nil || !member {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not authorized for chat"})
+		return
+	}
+
+	client := h.hub.addRoomClient(roomKey{id: chatID}, newClient(nil, userID, h.hub.cfg), sinceSeq)
+	defer h.hub.RemoveChatClient(chatID, client)
+	streamEvents(c, client, true)
+}
+
+// Group streams a group's events. Last-Event-ID (or since_seq) resumes after that seq.
+func (h *SSEHandler) Group(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+	sinceSeq, ok := streamCursor(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Last-Event-ID"})
+		return
+	}
+
+	userID, err := h.authenticate(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	member, err := h.groupRepo.IsMember(c.Request.Context(), groupID, userID)
+	if err != nil || !member {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not authorized for group"})
+		return
+	}
+
+	client := h.hub.addRoomClient(roomKey{group: true, id: groupID}, newClient(nil, userID, h.hub.cfg), sinceSeq)
+	defer h.hub.RemoveGroupClient(groupID, client)
+	streamEvents(c, client, true)
+}
+
+// User streams the events of all the caller's chats and groups, like the multiplexed websocket.
+// Sequence numbers differ per conversation, so events carry no id and cannot be resumed.
+func (h *SSEHandler) User(c *gin.Context) {
+	userID, err := h.authenticate(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	chatIDs, err := h.chatRepo.ListChatIDs(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load chats"})
+		return
+	}
+	groupIDs, err := h.groupRepo.ListGroupIDs(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load groups"})
+		return
+	}
+
+	client := h.hub.addUserClient(newClient(nil, userID, h.hub.cfg), chatIDs, groupIDs)
+	defer h.hub.RemoveUserClient(client)
+	streamEvents(c, client, false)
+}
+
+// authenticate validates the token from the Authorization header or, since EventSource cannot
+// set headers, the token query parameter.
+func (h *SSEHandler) authenticate(c *gin.Context) (int, error) {
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		token = c.Query("token")
+		if token != "" {
+			token = "Bearer " + token
+		}
+	}
+	return h.validateToken(c.Request.Context(), token)
+}
+
+func (h *SSEHandler) validateToken(ctx context.Context, header string) (int, error) {
+	parts := strings.Split(header, " ")
+	if len(parts) == 2 {
+		return h.authClient.ValidateToken(ctx, parts[1])
+	}
+	return 0, fmt.Errorf("invalid token")
+}
+
+// streamCursor reads the seq to resume after from Last-Event-ID, falling back to since_seq.
+func streamCursor(c *gin.Context) (*int64, bool) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		return sinceSeqQuery(c)
+	}
+	seq, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || seq < 0 {
+		return nil, false
+	}
+	return &seq, true
+}
+
+// streamEvents writes the client's queued events until the request ends or the client is
+// removed, commenting a ping every PingInterval to keep proxies from timing out the stream.
+func streamEvents(c *gin.Context, client *Client, withIDs bool) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	rc := http.NewResponseController(c.Writer)
+	write := func(fn func(io.Writer) error) bool {
+		if err := rc.SetWriteDeadline(time.Now().Add(client.cfg.WriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return false
+		}
+		if err := fn(c.Writer); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		return true
+	}
+
+	if !write(func(w io.Writer) error { _, err := io.WriteString(w, ": connected\n\n"); return err }) {
+		return
+	}
+
+	ticker := time.NewTicker(client.cfg.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case payload := <-client.send:
+			if !write(func(w io.Writer) error { return writeSSEEvent(w, payload, withIDs) }) {
+				return
+			}
+		case <-ticker.C:
+			if !write(func(w io.Writer) error { _, err := io.WriteString(w, ": ping\n\n"); return err }) {
+				return
+			}
+		case <-client.done:
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSEEvent frames one JSON payload. Sequenced events use their seq as the event id.
+func writeSSEEvent(w io.Writer, payload []byte, withID bool) error {
+	if withID {
+		var event struct {
+			Seq int64 `json:"seq"`
+		}
+		if err := json.Unmarshal(payload, &event); err == nil && event.Seq > 0 {
+			if _, err := fmt.Fprintf(w, "id: %d\n", event.Seq); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "data: %s\n\n", payload)
+	return err
+}
@@ -0,0 +1,104 @@
+package ws
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"chat-service/internal/models"
+)
+
+// sseEvent is one parsed Server-Sent Event.
+type sseEvent struct {
+	id   string
+	data map[string]any
+}
+
+func TestSSEStreamReplaysThenDeliversLiveEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hub := NewHub()
+	hub.BroadcastChatMessage(1, models.Message{ID: 5, ChatID: 1})
+	seq := hub.chatLogs[1].seq
+	hub.BroadcastDeletion(1, 4)
+
+	registered := make(chan struct{})
+	router := gin.New()
+	router.GET("/sse/chats/:chat_id", func(c *gin.Context) {
+		sinceSeq, ok := streamCursor(c)
+		if !ok {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		client := hub.addRoomClient(roomKey{id: 1}, newClient(nil, 10, hub.cfg), sinceSeq)
+		defer hub.RemoveChatClient(1, client)
+		close(registered)
+		streamEvents(c, client, true)
+	})
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/sse/chats/1", nil)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(seq, 10))
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected event stream, got %q", ct)
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	replayed := readSSEEvent(t, reader)
+	if replayed.data["type"] != "delete_for_all" || replayed.id != strconv.FormatInt(seq+1, 10) {
+		t.Fatalf("expected replayed deletion with id %d, got %+v", seq+1, replayed)
+	}
+
+	<-registered
+	hub.BroadcastChatRead(1, 11, 5)
+	live := readSSEEvent(t, reader)
+	if live.data["type"] != "read" || live.id != strconv.FormatInt(seq+2, 10) {
+		t.Fatalf("expected live read event, got %+v", live)
+	}
+}
+
+func TestWriteSSEEventOmitsIDForUnsequencedEvents(t *testing.T) {
+	var b strings.Builder
+	if err := writeSSEEvent(&b, []byte(`{"type":"typing_started","chat_id":1}`), true); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if got := b.String(); got != "data: {\"type\":\"typing_started\",\"chat_id\":1}\n\n" {
+		t.Fatalf("unexpected frame %q", got)
+	}
+}
+
+// readSSEEvent reads lines up to the next event, skipping comments.
+func readSSEEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.data != nil:
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.data); err != nil {
+				t.Fatalf("invalid data %q: %v", line, err)
+			}
+		}
+	}
+}
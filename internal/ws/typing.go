@@ -0,0 +1,116 @@
+package ws
+
+import (
+	"sync"
+	"time"
+
+	"chat-service/internal/models"
+)
+
+const (
+	// DefaultTypingTTL is how long a typing indicator lasts without a refresh from the client.
+	DefaultTypingTTL = 6 * time.Second
+	// TypingThrottle is the minimum interval between typing_started frames a connection may
+	// fan out per conversation; more frequent frames are acknowledged but dropped.
+	TypingThrottle = 2 * time.Second
+)
+
+// typingKey identifies one user typing in one conversation.
+type typingKey struct {
+	group  bool
+	id     int
+	userID int
+}
+
+type typingEntry struct {
+	timer *time.Timer
+}
+
+// typingTracker holds the active typing indicators. They are kept in memory only.
+type typingTracker struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	active map[typingKey]*typingEntry
+}
+
+func newTypingTracker(ttl time.Duration) *typingTracker {
+	return &typingTracker{ttl: ttl, active: make(map[typingKey]*typingEntry)}
+}
+
+// StartChatTyping marks userID as typing in a chat until StopChatTyping or the typing TTL elapses.
+func (h *Hub) StartChatTyping(chatID int, userID int) {
+	h.startTyping(typingKey{id: chatID, userID: userID})
+}
+
+// StopChatTyping clears userID's typing indicator in a chat, if any, on every instance.
+func (h *Hub) StopChatTyping(chatID int, userID int) {
+	h.stopTyping(typingKey{id: chatID, userID: userID})
+	h.relay(relayMessage{Op: relayStopTyping, ChatID: chatID, UserID: userID}, nil)
+}
+
+// StartGroupTyping marks userID as typing in a group until StopGroupTyping or the typing TTL elapses.
+func (h *Hub) StartGroupTyping(groupID int, userID int) {
+	h.startTyping(typingKey{group: true, id: groupID, userID: userID})
+}
+
+// StopGroupTyping clears userID's typing indicator in a group, if any, on every instance.
+func (h *Hub) StopGroupTyping(groupID int, userID int) {
+	h.stopTyping(typingKey{group: true, id: groupID, userID: userID})
+	h.relay(relayMessage{Op: relayStopTyping, GroupID: groupID, UserID: userID}, nil)
+}
+
+// startTyping announces typing_started for a new indicator and otherwise only extends its expiry.
+func (h *Hub) startTyping(key typingKey) {
+	t := h.typing
+	t.mu.Lock()
+	previous, active := t.active[key]
+	if active {
+		previous.timer.Stop()
+	}
+	entry := &typingEntry{}
+	entry.timer = time.AfterFunc(t.ttl, func() { h.expireTyping(key, entry) })
+	t.active[key] = entry
+	t.mu.Unlock()
+
+	if !active {
+		h.broadcastTyping(key, "typing_started")
+	}
+}
+
+func (h *Hub) stopTyping(key typingKey) {
+	t := h.typing
+	t.mu.Lock()
+	entry, active := t.active[key]
+	if active {
+		entry.timer.Stop()
+		delete(t.active, key)
+	}
+	t.mu.Unlock()
+
+	if active {
+		h.broadcastTyping(key, "typing_stopped")
+	}
+}
+
+// expireTyping ends an indicator whose client went quiet, unless it was refreshed meanwhile.
+func (h *Hub) expireTyping(key typingKey, entry *typingEntry) {
+	t := h.typing
+	t.mu.Lock()
+	if t.active[key] != entry {
+		t.mu.Unlock()
+		return
+	}
+	delete(t.active, key)
+	t.mu.Unlock()
+
+	h.broadcastTyping(key, "typing_stopped")
+}
+
+// broadcastTyping sends a typing event to everyone in the conversation except the typist.
+func (h *Hub) broadcastTyping(key typingKey, eventType string) {
+	if key.group {
+		h.sendGroup(key.id, models.GroupEvent{Type: eventType, UserID: key.userID}, key.userID)
+		return
+	}
+	h.sendChat(key.id, models.ChatEvent{Type: eventType, UserID: key.userID}, key.userID)
+}
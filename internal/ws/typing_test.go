@@ -0,0 +1,78 @@
+package ws
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestTypingFanOutSkipsSender(t *testing.T) {
+	hub := NewHub()
+	senderServer, senderClient := newTestConnPair(t)
+	peerServer, peerClient := newTestConnPair(t)
+	sender := hub.AddUserClient(10, senderServer, []int{1}, nil)
+	hub.AddUserClient(11, peerServer, []int{1}, nil)
+
+	d := &dispatcher{hub: hub, chats: &fakeChatActions{}}
+	if reply := d.handle(sender, []byte(`{"id":"t1","type":"typing_started","chat_id":1}`)); reply.Type != "ack" {
+		t.Fatalf("expected ack, got %+v", reply)
+	}
+	if event := readEvent(t, peerClient); event["type"] != "typing_started" || event["user_id"] != float64(10) || event["chat_id"] != float64(1) {
+		t.Fatalf("expected typing_started from user 10, got %v", event)
+	}
+	expectNoEvent(t, senderClient)
+
+	d.handle(sender, []byte(`{"id":"t2","type":"typing_stopped","chat_id":1}`))
+	if event := readEvent(t, peerClient); event["type"] != "typing_stopped" {
+		t.Fatalf("expected typing_stopped, got %v", event)
+	}
+}
+
+func TestTypingExpiresWithoutRefresh(t *testing.T) {
+	hub := NewHub()
+	hub.typing.ttl = 20 * time.Millisecond
+	peerServer, peerClient := newTestConnPair(t)
+	hub.AddGroupClient(2, 11, peerServer)
+
+	hub.StartGroupTyping(2, 10)
+	if event := readEvent(t, peerClient); event["type"] != "typing_started" {
+		t.Fatalf("expected typing_started, got %v", event)
+	}
+	if event := readEvent(t, peerClient); event["type"] != "typing_stopped" || event["group_id"] != float64(2) {
+		t.Fatalf("expected typing_stopped after expiry, got %v", event)
+	}
+}
+
+func TestTypingThrottledPerConnection(t *testing.T) {
+	hub := NewHub()
+	peerServer, peerClient := newTestConnPair(t)
+	hub.AddChatClient(1, 11, peerServer)
+
+	d := &dispatcher{hub: hub, chats: &fakeChatActions{}, chatID: 1}
+	sender := newClient(nil, 10, DefaultHubConfig())
+	d.handle(sender, []byte(`{"id":"t1","type":"typing_started"}`))
+	readEvent(t, peerClient)
+
+	// the indicator is cleared behind the connection's back; a frame inside the throttle window
+	// must not start a new one
+	hub.StopChatTyping(1, 10)
+	readEvent(t, peerClient)
+	d.handle(sender, []byte(`{"id":"t2","type":"typing_started"}`))
+	expectNoEvent(t, peerClient)
+}
+
+// expectNoEvent fails if the client receives a frame shortly.
+func expectNoEvent(t *testing.T, conn *websocket.Conn) {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	_, payload, err := conn.ReadMessage()
+	if err == nil {
+		t.Fatalf("expected no event, got %s", payload)
+	}
+	if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		t.Fatalf("expected read timeout, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package ws
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+
+	"chat-service/internal/models"
+)
+
+// userSession holds a user's multiplexed connections and the conversations they receive events for.
+type userSession struct {
+	clients map[*Client]struct{}
+	chats   map[int]struct{}
+	groups  map[int]struct{}
+}
+
+// AddUserClient registers a multiplexed connection and subscribes the user to chatIDs and groupIDs.
+func (h *Hub) AddUserClient(userID int, conn *websocket.Conn, chatIDs []int, groupIDs []int) *Client {
+	return h.addUserClient(h.startClient(conn, userID), chatIDs, groupIDs)
+}
+
+func (h *Hub) addUserClient(client *Client, chatIDs []int, groupIDs []int) *Client {
+	userID := client.userID
+	blocked := h.loadBlocks(userID)
+	h.mu.Lock()
+
+	h.storeBlocksLocked(userID, blocked)
+	session, ok := h.users[userID]
+	if !ok {
+		session = &userSession{
+			clients: make(map[*Client]struct{}),
+			chats:   make(map[int]struct{}),
+			groups:  make(map[int]struct{}),
+		}
+		h.users[userID] = session
+	}
+	session.clients[client] = struct{}{}
+	for _, chatID := range chatIDs {
+		subscribe(h.chatUsers, session.chats, chatID, userID)
+	}
+	for _, groupID := range groupIDs {
+		subscribe(h.groupUsers, session.groups, groupID, userID)
+	}
+	listener := h.connectedLocked(userID)
+	h.mu.Unlock()
+
+	if listener != nil {
+		listener.UserConnected(userID)
+	}
+	return client
+}
+
+// RemoveUserClient removes a multiplexed connection. Subscriptions are dropped with the user's last connection.
+func (h *Hub) RemoveUserClient(client *Client) {
+	h.mu.Lock()
+	listener := h.removeUserClientLocked(client)
+	h.mu.Unlock()
+
+	if listener != nil {
+		listener.UserDisconnected(client.userID)
+	}
+}
+
+func (h *Hub) removeUserClientLocked(client *Client) PresenceListener {
+	userID := client.userID
+	session, ok := h.users[userID]
+	if !ok {
+		return nil
+	}
+	if _, ok := session.clients[client]; !ok {
+		return nil
+	}
+	client.close()
+	delete(session.clients, client)
+	listener := h.disconnectedLocked(userID)
+	if len(session.clients) > 0 {
+		return listener
+	}
+	for chatID := range session.chats {
+		unsubscribe(h.chatUsers, nil, chatID, userID)
+	}
+	for groupID := range session.groups {
+		unsubscribe(h.groupUsers, nil, groupID, userID)
+	}
+	delete(h.users, userID)
+	return listener
+}
+
+// SubscribeChat starts delivering a chat's events to the multiplexed connections of userIDs.
+// Users without a live connection are skipped; they load their chats when they connect.
+func (h *Hub) SubscribeChat(chatID int, userIDs ...int) {
+	h.subscribeChatLocal(chatID, userIDs...)
+	h.relay(relayMessage{Op: relaySubscribeChat, ChatID: chatID, UserIDs: userIDs}, nil)
+}
+
+func (h *Hub) subscribeChatLocal(chatID int, userIDs ...int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, userID := range userIDs {
+		if session, ok := h.users[userID]; ok {
+			subscribe(h.chatUsers, session.chats, chatID, userID)
+		}
+	}
+}
+
+// SubscribeGroup starts delivering a group's events to the multiplexed connections of userIDs
+// and notifies them with a group_joined event.
+func (h *Hub) SubscribeGroup(groupID int, userIDs ...int) {
+	h.subscribeGroupLocal(groupID, userIDs...)
+	h.relay(relayMessage{Op: relaySubscribeGroup, GroupID: groupID, UserIDs: userIDs}, nil)
+}
+
+func (h *Hub) subscribeGroupLocal(groupID int, userIDs ...int) {
+	h.mu.Lock()
+	var joined []int
+	for _, userID := range userIDs {
+		if session, ok := h.users[userID]; ok {
+			subscribe(h.groupUsers, session.groups, groupID, userID)
+			joined = append(joined, userID)
+		}
+	}
+	h.mu.Unlock()
+
+	h.notifyUsers(joined, models.GroupEvent{Type: "group_joined", GroupID: groupID})
+}
+
+// UnsubscribeGroup stops delivering a group's events to the user and notifies the user's
+// multiplexed connections with a group_left event.
+func (h *Hub) UnsubscribeGroup(groupID int, userID int) {
+	h.unsubscribeGroupLocal(groupID, userID)
+	h.relay(relayMessage{Op: relayUnsubscribeGroup, GroupID: groupID, UserID: userID}, nil)
+}
+
+func (h *Hub) unsubscribeGroupLocal(groupID int, userID int) {
+	h.mu.Lock()
+	session, ok := h.users[userID]
+	if ok {
+		unsubscribe(h.groupUsers, session.groups, groupID, userID)
+	}
+	h.mu.Unlock()
+
+	if ok {
+		h.notifyUsers([]int{userID}, models.GroupEvent{Type: "group_left", GroupID: groupID})
+	}
+}
+
+// subscribers returns the ids of users subscribed to a conversation, excluding skipUserID.
+func (h *Hub) subscribers(index map[int]map[int]struct{}, id int, skipUserID int) []int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	userIDs := make([]int, 0, len(index[id]))
+	for userID := range index[id] {
+		if userID != skipUserID {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs
+}
+
+// subscribed reports whether the user's multiplexed connections receive a conversation's events.
+func (h *Hub) subscribed(index map[int]map[int]struct{}, id int, userID int) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := index[id][userID]
+	return ok
+}
+
+func (h *Hub) notifyUsers(userIDs []int, event any) {
+	if len(userIDs) == 0 {
+		return
+	}
+	payload, _ := json.Marshal(event)
+	h.deliverToUsers(userIDs, payload)
+}
+
+// deliverToUsers writes payload to every multiplexed connection of userIDs.
+func (h *Hub) deliverToUsers(userIDs []int, payload []byte) {
+	h.mu.RLock()
+	var clients []*Client
+	for _, userID := range userIDs {
+		if session, ok := h.users[userID]; ok {
+			for client := range session.clients {
+				clients = append(clients, client)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		client.write(payload)
+	}
+}
+
+func subscribe(index map[int]map[int]struct{}, owned map[int]struct{}, id int, userID int) {
+	if _, ok := index[id]; !ok {
+		index[id] = make(map[int]struct{})
+	}
+	index[id][userID] = struct{}{}
+	owned[id] = struct{}{}
+}
+
+func unsubscribe(index map[int]map[int]struct{}, owned map[int]struct{}, id int, userID int) {
+	if users, ok := index[id]; ok {
+		delete(users, userID)
+		if len(users) == 0 {
+			delete(index, id)
+		}
+	}
+	if owned != nil {
+		delete(owned, id)
+	}
+}
@@ -0,0 +1,86 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	grpcclient "chat-service/internal/grpc"
+	"chat-service/internal/repositories"
+)
+
+// UserWebSocketHandler handles the multiplexed websocket that carries events for all of a user's
+// chats and groups over a single connection.
+type UserWebSocketHandler struct {
+	hub        *Hub
+	chatRepo   repositories.ChatRepository
+	groupRepo  repositories.GroupRepository
+	authClient *grpcclient.AuthClient
+	chats      ChatActions
+	groups     GroupActions
+}
+
+// NewUserWebSocketHandler constructs a UserWebSocketHandler.
+func NewUserWebSocketHandler(hub *Hub, chatRepo repositories.ChatRepository, groupRepo repositories.GroupRepository, authClient *grpcclient.AuthClient) *UserWebSocketHandler {
+	return &UserWebSocketHandler{hub: hub, chatRepo: chatRepo, groupRepo: groupRepo, authClient: authClient}
+}
+
+// SetActions enables client requests on the multiplexed socket.
+func (h *UserWebSocketHandler) SetActions(chats ChatActions, groups GroupActions) {
+	h.chats = chats
+	h.groups = groups
+}
+
+// Handle authenticates the caller, upgrades the connection and subscribes it to the user's conversations.
+func (h *UserWebSocketHandler) Handle(c *gin.Context) {
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		token = c.Query("token")
+		if token != "" {
+			token = "Bearer " + token
+		}
+	}
+
+	userID, err := h.validateToken(c.Request.Context(), token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	chatIDs, err := h.chatRepo.ListChatIDs(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load chats"})
+		return
+	}
+	groupIDs, err := h.groupRepo.ListGroupIDs(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load groups"})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	client := h.hub.AddUserClient(userID, conn, chatIDs, groupIDs)
+
+	go func() {
+		defer func() {
+			h.hub.RemoveUserClient(client)
+			conn.Close()
+		}()
+		d := &dispatcher{hub: h.hub, chats: h.chats, groups: h.groups}
+		d.serve(client)
+	}()
+}
+
+func (h *UserWebSocketHandler) validateToken(ctx context.Context, header string) (int, error) {
+	parts := strings.Split(header, " ")
+	if len(parts) == 2 {
+		return h.authClient.ValidateToken(ctx, parts[1])
+	}
+	return 0, fmt.Errorf("invalid token")
+}
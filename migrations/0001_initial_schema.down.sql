@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS group_messages;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS chat_visibility;
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS chats;
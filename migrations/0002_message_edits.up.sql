@@ -0,0 +1,13 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ;
+ALTER TABLE group_messages ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS message_edits (
+    id SERIAL PRIMARY KEY,
+    scope TEXT NOT NULL CHECK (scope IN ('chat', 'group')),
+    message_id INT NOT NULL,
+    editor_id INT NOT NULL,
+    previous_content TEXT NOT NULL,
+    edited_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_edits_scope_message_id ON message_edits (scope, message_id);
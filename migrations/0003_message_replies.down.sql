@@ -0,0 +1,2 @@
+ALTER TABLE group_messages DROP COLUMN IF EXISTS reply_to_message_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS reply_to_message_id;
@@ -0,0 +1,2 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS reply_to_message_id INT REFERENCES messages(id) ON DELETE SET NULL;
+ALTER TABLE group_messages ADD COLUMN IF NOT EXISTS reply_to_message_id INT REFERENCES group_messages(id) ON DELETE SET NULL;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS message_reactions (
+    scope TEXT NOT NULL CHECK (scope IN ('chat', 'group')),
+    message_id INT NOT NULL,
+    user_id INT NOT NULL,
+    emoji TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (scope, message_id, user_id, emoji)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE groups DROP COLUMN IF EXISTS avatar_url;
+ALTER TABLE groups DROP COLUMN IF EXISTS description;
+ALTER TABLE group_members DROP COLUMN IF EXISTS role;
+ALTER TABLE group_messages DROP COLUMN IF EXISTS kind;
+ALTER TABLE group_members DROP COLUMN IF EXISTS joined_at;
@@ -0,0 +1,9 @@
+ALTER TABLE group_members ADD COLUMN IF NOT EXISTS joined_at TIMESTAMPTZ DEFAULT NOW();
+ALTER TABLE group_messages ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'user';
+ALTER TABLE group_members ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'member';
+
+UPDATE group_members gm SET role = 'owner' FROM groups g
+    WHERE gm.group_id = g.id AND gm.user_id = g.owner_id AND gm.role <> 'owner';
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS avatar_url TEXT NOT NULL DEFAULT '';
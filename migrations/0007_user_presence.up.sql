@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS user_presence (
+    user_id INT PRIMARY KEY,
+    last_seen_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,2 @@
+ALTER TABLE group_messages DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE messages DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    id SERIAL PRIMARY KEY,
+    uploader_id INT NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    scope TEXT CHECK (scope IN ('chat', 'group')),
+    message_id INT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK ((scope IS NULL) = (message_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_scope_message_id ON attachments (scope, message_id);
@@ -0,0 +1,3 @@
+-- chat_members predates the chats table and is no longer read or written.
+-- The former legacy_cleanup.sql also dropped groups and group_members, which are in use and kept.
+DROP TABLE IF EXISTS chat_members;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS processed_events;
+ALTER TABLE chats DROP COLUMN IF EXISTS read_only_reason;
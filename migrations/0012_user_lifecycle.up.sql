@@ -0,0 +1,9 @@
+-- Chats stop accepting messages once the participants are no longer friends or an account is deleted.
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS read_only_reason TEXT;
+
+-- Ids of the consumed user-service events already applied, so redeliveries are ignored.
+CREATE TABLE IF NOT EXISTS processed_events (
+    event_id TEXT PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+// Package migrations embeds the versioned SQL migrations of the service database.
+//
+// Files are named NNNN_description.up.sql and NNNN_description.down.sql. Versions are applied
+// in ascending order, each in its own transaction. A migration without a down file cannot be
+// rolled back.
+package migrations
+
+import "embed"
+
+// FS holds the migration files.
+//
+//go:embed *.sql
+var FS embed.FS